@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+)
+
+// getJSON decodes the response to a GET of path of the API into v, for fields the SDK does not decode
+func getJSON(client *predixinsights.Client, path string, v interface{}) error {
+	req, err := http.NewRequest("GET", client.APIHost+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("predix-zone-id", client.TenantID)
+	req.Header.Add("authorization", client.Token)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != 200 {
+		return fmt.Errorf("request returned %d. Body: %s", res.StatusCode, string(body))
+	}
+	return json.Unmarshal(body, v)
+}
+
+// uploadPart is a form field of a multipart upload, or a file when fileName is set
+type uploadPart struct {
+	field, fileName string
+	value           []byte
+}
+
+// postMultipart posts parts to path of the API as the SDK does and returns the response body, a status want rejects is
+// an error
+func postMultipart(client *predixinsights.Client, path string, parts []uploadPart, want func(int) bool) ([]byte, error) {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	for _, p := range parts {
+		var pw io.Writer
+		var err error
+		if p.fileName != "" {
+			pw, err = w.CreateFormFile(p.field, p.fileName)
+		} else {
+			pw, err = w.CreateFormField(p.field)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := pw.Write(p.value); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", client.APIHost+path, &b)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("predix-zone-id", client.TenantID)
+	req.Header.Add("authorization", client.Token)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if !want(res.StatusCode) {
+		return nil, fmt.Errorf("upload request returned %d. Body: %s", res.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+// postFlowDirectly uploads a direct flow with tags and spark arguments, the SDK always posts it without and a direct
+// flow has no flow template to save them through afterwards
+func postFlowDirectly(client *predixinsights.Client, flowName, flowFileName, flowFilePath, version, desc, flowType string, tags []string, sparkArgs *predixinsights.SparkArguments) (predixinsights.FlowDirectUploadResponse, error) {
+	if len(tags) == 0 && sparkArgs == nil {
+		return client.PostFlowDirectly(flowName, flowFileName, flowFilePath, version, desc, flowType)
+	}
+	fields := map[string]interface{}{"version": version, "user": client.ClientID, "name": flowName, "description": desc, "type": flowType, "tags": tags}
+	if tags == nil {
+		fields["tags"] = []string{}
+	}
+	if sparkArgs != nil {
+		fields["sparkArguments"] = sparkArgs
+	}
+	metadata, err := json.Marshal(fields)
+	if err != nil {
+		return predixinsights.FlowDirectUploadResponse{}, err
+	}
+	contents, err := ioutil.ReadFile(flowFilePath)
+	if err != nil {
+		return predixinsights.FlowDirectUploadResponse{}, err
+	}
+	body, err := postMultipart(client, "/api/v1/flows", []uploadPart{{"metadata", "", metadata}, {"file", flowFileName, contents}}, func(status int) bool { return status == 201 })
+	if err != nil {
+		return predixinsights.FlowDirectUploadResponse{}, err
+	}
+	var flow predixinsights.FlowDirectUploadResponse
+	err = json.Unmarshal(body, &flow)
+	return flow, err
+}
+
+// addConfigFiles uploads config files to a flow, the ones in rendered from memory instead of their FileLocation
+func addConfigFiles(client *predixinsights.Client, flowID string, fileDetails []predixinsights.FileDetails, rendered map[string][]byte) error {
+	if len(rendered) == 0 {
+		return client.UpdateFlowByFlowIDAddConfigFile(flowID, fileDetails)
+	}
+	parts := []uploadPart{}
+	for i, f := range fileDetails {
+		for j := range f.Fields {
+			parts = append(parts, uploadPart{f.Fields[j], "", []byte(f.Values[j])})
+		}
+		contents, err := readUpload(f, rendered)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, uploadPart{fmt.Sprintf("File%d", i), f.FileName, contents})
+	}
+	_, err := postMultipart(client, "/api/v1/flows/"+flowID+"/config", parts, func(status int) bool { return status >= 200 && status <= 299 })
+	return err
+}
+
+// readUpload returns the contents of a config file about to be uploaded
+func readUpload(f predixinsights.FileDetails, rendered map[string][]byte) ([]byte, error) {
+	if b, ok := rendered[f.FileName]; ok {
+		return b, nil
+	}
+	return ioutil.ReadFile(f.FileLocation)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Apply a Manifest",
+	Long: `Reconcile flow templates, flows, config files, tags, DAGs and dependencies to a YAML or JSON manifest.
+Resources are matched by name, so applying the same manifest again updates rather than duplicates them.
+With --plan the changes are only printed, and the exit code is 2 when changes are pending.`,
+	Example: "  pi apply -f manifest.yaml --plan\n  pi apply -f manifest.yaml\n  pi apply -f manifest.yaml --prune",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := login()
+		if err != nil {
+			fmt.Println("authentication error err=" + err.Error())
+			os.Exit(exitError)
+		}
+		err = getMissingRequiredParams(applyPI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			os.Exit(exitError)
+		}
+		m, err := loadManifest(applyPI.V.GetString("file"))
+		if err != nil {
+			fmt.Println("error loading manifest err=" + err.Error())
+			os.Exit(exitError)
+		}
+		plan, err := newApplyPlan(client, m, applyPI.V.GetBool("prune"))
+		if err != nil {
+			fmt.Println("error planning manifest err=" + err.Error())
+			os.Exit(exitError)
+		}
+		plan.print(os.Stdout)
+		if applyPI.V.GetBool("plan") {
+			if plan.pending() > 0 {
+				os.Exit(exitChanges)
+			}
+			return
+		}
+		if plan.pending() == 0 {
+			fmt.Println("No changes. The tenant matches the manifest.")
+			return
+		}
+		fmt.Println()
+		err = plan.execute(os.Stdout)
+		if err != nil {
+			fmt.Println("error applying manifest err=" + err.Error())
+			os.Exit(exitError)
+		}
+		fmt.Printf("Apply complete. %d resource(s) changed.\n", plan.pending())
+	},
+}
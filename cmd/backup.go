@@ -0,0 +1,480 @@
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// backupIndexFile is the first entry of a backup archive
+	backupIndexFile = "backup.json"
+	// backupResourcesDir holds the exported manifests, config files and artifacts inside the archive
+	backupResourcesDir = "resources"
+	backupFormat       = 1
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Backup & Restore a Tenant",
+	Long:  `Backup every resource of a tenant to a single archive and restore it into the same or a different tenant.`,
+}
+
+func init() {
+	RootCmd.AddCommand(backupCmd)
+}
+
+var createBackupCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a Backup Archive",
+	Long: `Write every flow template, flow, spark arguments set, tag, config file, DAG record and dependency record to a tar.gz archive.
+The API does not return flow template artifacts, DAG files, dependency files or config files other than JSON ones, they are added from --artifactDir when found there by file name.
+The archive carries backup.json with the backup time, the IDs of the resources and a sha256 checksum of every file.`,
+	Example: "  pi backup create --file tenant.tar.gz --artifactDir build/",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := login()
+		if err != nil {
+			fmt.Println("authentication error err=" + err.Error())
+			os.Exit(exitError)
+		}
+		err = getMissingRequiredParams(createBackupPI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			os.Exit(exitError)
+		}
+		out := createBackupPI.V.GetString("file")
+		if out == "" {
+			out = "pi-backup-" + time.Now().UTC().Format("20060102T150405Z") + ".tar.gz"
+		}
+		index, err := createBackup(client, out, createBackupPI.V.GetString("artifactDir"))
+		if err != nil {
+			fmt.Println("error creating backup err=" + err.Error())
+			os.Exit(exitError)
+		}
+		for _, missing := range index.Missing {
+			fmt.Println("not backed up: " + missing)
+		}
+		fmt.Printf("Backup written to %s: %d resource(s), %d file(s), %d artifact(s) missing\n", out, len(index.IDs), len(index.Files), len(index.Missing))
+	},
+}
+
+var restoreBackupCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a Backup Archive",
+	Long: `Recreate the resources of a backup archive, created with pi backup create, in the tenant of the current config.
+Resources are matched by name, existing ones are updated. The server generates new IDs, the mapping from the IDs in the backup is printed and written to --idMap.
+Resources that could not be restored are reported at the end and make the exit code 1.`,
+	Example: "  pi backup restore --file tenant.tar.gz --plan\n  pi backup restore --file tenant.tar.gz --idMap ids.json\n  pi backup restore --file tenant.tar.gz --config ~/.pi/other-tenant.json",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := login()
+		if err != nil {
+			fmt.Println("authentication error err=" + err.Error())
+			os.Exit(exitError)
+		}
+		err = getMissingRequiredParams(restoreBackupPI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			os.Exit(exitError)
+		}
+		dir, err := ioutil.TempDir("", "pi-restore")
+		if err != nil {
+			fmt.Println("error creating temporary directory err=" + err.Error())
+			os.Exit(exitError)
+		}
+		defer os.RemoveAll(dir)
+		index, err := extractBackup(restoreBackupPI.V.GetString("file"), dir)
+		if err != nil {
+			fmt.Println("error reading backup err=" + err.Error())
+			os.RemoveAll(dir)
+			os.Exit(exitError)
+		}
+		fmt.Printf("Restoring backup of tenant %s taken %s\n", index.TenantID, index.Created)
+		failed, err := restoreBackup(client, index, dir, restoreBackupPI.V.GetBool("plan"), restoreBackupPI.V.GetString("idMap"))
+		if err != nil {
+			fmt.Println("error restoring backup err=" + err.Error())
+			os.RemoveAll(dir)
+			os.Exit(exitError)
+		}
+		if len(failed) > 0 {
+			fmt.Println("\nNot restored:")
+			for _, f := range failed {
+				fmt.Println("  " + f)
+			}
+			os.RemoveAll(dir)
+			os.Exit(exitError)
+		}
+	},
+}
+
+// backupIndex is stored as backup.json in the archive
+type backupIndex struct {
+	Format     int               `json:"format"`
+	Created    string            `json:"created"`
+	CLIVersion string            `json:"cliVersion"`
+	APIHost    string            `json:"apiHost"`
+	TenantID   string            `json:"tenantID"`
+	IDs        map[string]string `json:"ids"`
+	Files      []backupFile      `json:"files"`
+	Missing    []string          `json:"missing,omitempty"`
+}
+
+// backupFile is a file of the archive, Path is relative to the resources directory
+type backupFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// createBackup exports the tenant to a temporary directory, adds the artifacts found in artifactDir and archives it to out
+func createBackup(client *predixinsights.Client, out, artifactDir string) (*backupIndex, error) {
+	dir, err := ioutil.TempDir("", "pi-backup")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	m, ids, err := exportManifest(client, exportSelector{}, dir)
+	if err != nil {
+		return nil, err
+	}
+	index := &backupIndex{
+		Format:     backupFormat,
+		Created:    time.Now().UTC().Format(time.RFC3339),
+		CLIVersion: Version,
+		APIHost:    client.APIHost,
+		TenantID:   client.TenantID,
+		IDs:        ids,
+		Files:      []backupFile{},
+		Missing:    []string{},
+	}
+	artifacts := map[string]string{}
+	for _, ft := range m.FlowTemplates {
+		artifacts[ft.File] = "flow-template " + ft.Name
+	}
+	for _, d := range m.DAGs {
+		artifacts[d.File] = "dag " + d.Name
+	}
+	for _, d := range m.Dependencies {
+		artifacts[d.File] = "dependency " + d.Name
+	}
+	// the API only returns JSON config files, the others are looked up in artifactDir like artifacts
+	for _, f := range m.Flows {
+		for _, cf := range f.ConfigFiles {
+			path := filepath.Join(dir, filepath.FromSlash(cf.File))
+			if _, err := os.Stat(path); err == nil {
+				continue
+			}
+			owner := fmt.Sprintf("flow %s: config file %s", f.Name, cf.Name)
+			if artifactDir == "" {
+				index.Missing = append(index.Missing, owner+", no --artifactDir given")
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+				return nil, err
+			}
+			if err := copyFile(filepath.Join(artifactDir, cf.Name), path); err != nil {
+				index.Missing = append(index.Missing, fmt.Sprintf("%s err=%s", owner, err.Error()))
+			}
+		}
+	}
+	for _, name := range sortedKeys(artifacts) {
+		owner := artifacts[name]
+		if name == "" {
+			index.Missing = append(index.Missing, owner+": no artifact recorded")
+			continue
+		}
+		if artifactDir == "" {
+			index.Missing = append(index.Missing, fmt.Sprintf("%s: artifact %s, no --artifactDir given", owner, name))
+			continue
+		}
+		if err := copyFile(filepath.Join(artifactDir, name), filepath.Join(dir, name)); err != nil {
+			index.Missing = append(index.Missing, fmt.Sprintf("%s: artifact %s err=%s", owner, name, err.Error()))
+		}
+	}
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		index.Files = append(index.Files, backupFile{Path: filepath.ToSlash(rel), Size: info.Size(), SHA256: sum})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return index, writeBackup(out, dir, index)
+}
+
+// writeBackup writes backup.json followed by the indexed files of dir to a tar.gz archive
+func writeBackup(out, dir string, index *backupIndex) error {
+	b, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	modTime := time.Now()
+	if err := tw.WriteHeader(&tar.Header{Name: backupIndexFile, Mode: 0644, Size: int64(len(b)), ModTime: modTime}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(b); err != nil {
+		return err
+	}
+	for _, bf := range index.Files {
+		content, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(bf.Path)))
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{Name: backupResourcesDir + "/" + bf.Path, Mode: 0644, Size: int64(len(content)), ModTime: modTime}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// extractBackup unpacks the resources of an archive to dir and verifies them against backup.json
+func extractBackup(path, dir string) (*backupIndex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+	var index *backupIndex
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+		if hdr.Name == backupIndexFile {
+			index = &backupIndex{}
+			if err := json.NewDecoder(tr).Decode(index); err != nil {
+				return nil, fmt.Errorf("invalid %s err=%s", backupIndexFile, err.Error())
+			}
+			continue
+		}
+		rel := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(hdr.Name, backupResourcesDir+"/")))
+		if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("archive entry %s is outside of the backup", hdr.Name)
+		}
+		target := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			return nil, err
+		}
+		out, err := os.Create(target)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(out, tr)
+		out.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if index == nil {
+		return nil, fmt.Errorf("%s is not a backup, %s is missing", path, backupIndexFile)
+	}
+	if index.Format != backupFormat {
+		return nil, fmt.Errorf("unsupported backup format %d", index.Format)
+	}
+	for _, bf := range index.Files {
+		sum, err := fileSHA256(filepath.Join(dir, filepath.FromSlash(bf.Path)))
+		if err != nil {
+			return nil, fmt.Errorf("%s is missing from the archive", bf.Path)
+		}
+		if sum != bf.SHA256 {
+			return nil, fmt.Errorf("checksum mismatch for %s, the archive is corrupt", bf.Path)
+		}
+	}
+	return index, nil
+}
+
+// restoreBackup applies the backed up resources that can be restored and returns a description of those that could not
+func restoreBackup(client *predixinsights.Client, index *backupIndex, dir string, planOnly bool, idMapFile string) ([]string, error) {
+	m, err := readManifests(dir)
+	if err != nil {
+		return nil, err
+	}
+	failed := []string{}
+	exists := func(path string) bool {
+		_, err := os.Stat(path)
+		return path != "" && err == nil
+	}
+	restorable := &manifest{}
+	dropped := map[string]bool{}
+	for _, ft := range m.FlowTemplates {
+		if !exists(ft.File) {
+			failed = append(failed, fmt.Sprintf("flow-template %s: artifact %s is not in the backup", ft.Name, filepath.Base(ft.File)))
+			dropped[ft.Name] = true
+			continue
+		}
+		restorable.FlowTemplates = append(restorable.FlowTemplates, ft)
+	}
+	for _, f := range m.Flows {
+		for _, cf := range f.ConfigFiles {
+			if !exists(cf.File) {
+				// restoring part of the config files would delete the others from an existing flow
+				failed = append(failed, fmt.Sprintf("flow %s: config file %s is not in the backup, the config files were not restored", f.Name, cf.Name))
+				f.ConfigFiles = nil
+				break
+			}
+		}
+		if dropped[f.Template] {
+			// the flow can still be restored when the target tenant has the template
+			res, err := client.GetFlowTemplateByName(f.Template)
+			if err != nil || !containsTemplate(res.Content, f.Template) {
+				failed = append(failed, fmt.Sprintf("flow %s: flow template %s was not restored", f.Name, f.Template))
+				continue
+			}
+		}
+		restorable.Flows = append(restorable.Flows, f)
+	}
+	for _, d := range m.DAGs {
+		if !exists(d.File) || d.Version == "" {
+			failed = append(failed, fmt.Sprintf("dag %s: the DAG file and version are not in the backup", d.Name))
+			continue
+		}
+		restorable.DAGs = append(restorable.DAGs, d)
+	}
+	for _, d := range m.Dependencies {
+		if !exists(d.File) {
+			failed = append(failed, fmt.Sprintf("dependency %s: file %s is not in the backup", d.Name, filepath.Base(d.File)))
+			continue
+		}
+		restorable.Dependencies = append(restorable.Dependencies, d)
+	}
+
+	plan, err := newApplyPlan(client, restorable, false)
+	if err != nil {
+		return nil, err
+	}
+	plan.print(os.Stdout)
+	if planOnly {
+		return failed, nil
+	}
+	if plan.pending() > 0 {
+		fmt.Println()
+		for _, err := range plan.executeAll(os.Stdout) {
+			failed = append(failed, err.Error())
+		}
+	}
+
+	current, err := tenantIDs(client)
+	if err != nil {
+		return nil, err
+	}
+	idMap := map[string]string{}
+	fmt.Println("\nIDs:")
+	for _, key := range sortedKeys(index.IDs) {
+		old := index.IDs[key]
+		id, ok := current[key]
+		if !ok {
+			continue
+		}
+		idMap[old] = id
+		fmt.Printf("  %s: %s -> %s\n", key, old, id)
+	}
+	if idMapFile != "" {
+		b, err := json.MarshalIndent(idMap, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(idMapFile, append(b, '\n'), os.FileMode(0644)); err != nil {
+			return nil, err
+		}
+	}
+	return failed, nil
+}
+
+func containsTemplate(templates []predixinsights.FlowTemplate, name string) bool {
+	for _, ft := range templates {
+		if ft.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// tenantIDs returns the IDs of every resource of the tenant keyed as recorded in backup.json, see exportManifest
+func tenantIDs(client *predixinsights.Client) (map[string]string, error) {
+	ids := map[string]string{}
+	templates, err := client.GetAllFlowTemplatesByPage(maxTemplatePages)
+	if err != nil {
+		return nil, err
+	}
+	for _, ft := range templates {
+		ids["flow-template/"+ft.Name] = ft.ID
+	}
+	flows, err := client.GetAllFlows(maxFlowPages)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range flows {
+		ids["flow/"+flowKey(f.FlowTemplate.Name, f.Name)] = f.ID
+	}
+	dags, err := client.GetAllDAGs()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range dags.Content {
+		ids["dag/"+d.Name] = d.ID
+	}
+	deps, err := client.GetAllDependencies()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deps.Content {
+		ids["dependency/"+d.Name] = d.ID
+	}
+	return ids, nil
+}
+
+func copyFile(src, dst string) error {
+	b, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, b, os.FileMode(0644))
+}
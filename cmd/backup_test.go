@@ -0,0 +1,335 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+)
+
+// fakeDAG is a DAG of a fakeTenant, the owner and interval are read from the rendered DAG file
+type fakeDAG struct {
+	predixinsights.DAGResponse
+	Version  string `json:"version"`
+	owner    string
+	interval string
+}
+
+// fakeTenant serves the part of the API backup and restore use from memory
+type fakeTenant struct {
+	t         *testing.T
+	mux       sync.Mutex
+	lastID    int
+	templates map[string]*predixinsights.FlowTemplate
+	flows     map[string]*predixinsights.Flow
+	configs   map[string]map[string]string
+	dags      map[string]*fakeDAG
+}
+
+func newFakeTenant(t *testing.T) *fakeTenant {
+	return &fakeTenant{t: t, templates: map[string]*predixinsights.FlowTemplate{}, flows: map[string]*predixinsights.Flow{}, configs: map[string]map[string]string{}, dags: map[string]*fakeDAG{}}
+}
+
+func (f *fakeTenant) id() string {
+	f.lastID++
+	return fmt.Sprintf("id-%d", f.lastID)
+}
+
+func (f *fakeTenant) addTemplate(ft predixinsights.FlowTemplate) *predixinsights.FlowTemplate {
+	ft.ID = f.id()
+	f.templates[ft.ID] = &ft
+	return &ft
+}
+
+func (f *fakeTenant) addFlow(ft *predixinsights.FlowTemplate, name string) *predixinsights.Flow {
+	fl := &predixinsights.Flow{ID: f.id(), Name: name, Type: ft.Type, Version: ft.Version, SparkArgs: ft.SparkArgs, FlowTemplate: predixinsights.FlowTemplate{ID: ft.ID, Name: ft.Name}}
+	f.flows[fl.ID] = fl
+	f.configs[fl.ID] = map[string]string{}
+	return fl
+}
+
+func (f *fakeTenant) addDAG(name, version, desc, flowType, file string) *fakeDAG {
+	d := &fakeDAG{DAGResponse: predixinsights.DAGResponse{ID: f.id(), Name: name, Description: desc, Type: flowType, BlobPath: "dags/" + file}, Version: version}
+	f.dags[name] = d
+	return d
+}
+
+var (
+	fakeTemplatePath   = regexp.MustCompile(`^/api/v1/flow-templates/([^/]+)(/tags|/flows)?$`)
+	fakeFlowPath       = regexp.MustCompile(`^/api/v1/flow-templates/([^/]+)/flows/([^/]+)(/tags)?$`)
+	fakeConfigPath     = regexp.MustCompile(`^/api/v1/flows/([^/]+)/config$`)
+	fakeDAGPath        = regexp.MustCompile(`^/api/v1/dags/([^/]+)(/deploy)?$`)
+	fakeDAGStatusPath  = regexp.MustCompile(`^/api/v1/dags/status/([^/]+)$`)
+	fakeDAGFileContent = regexp.MustCompile(`owner=(.*)\ninterval=(.*)\n`)
+)
+
+func (f *fakeTenant) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	reply := func(status int, v interface{}) {
+		w.WriteHeader(status)
+		if v != nil {
+			json.NewEncoder(w).Encode(v)
+		}
+	}
+	decode := func(v interface{}) {
+		if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+			f.t.Errorf("%s %s: %s", r.Method, r.URL, err.Error())
+		}
+	}
+	metadata := func() (map[string]string, map[string]string) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			f.t.Errorf("%s %s: %s", r.Method, r.URL, err.Error())
+			return nil, nil
+		}
+		meta, files := map[string]string{}, map[string]string{}
+		if v := r.MultipartForm.Value["metadata"]; len(v) > 0 {
+			json.Unmarshal([]byte(v[0]), &meta)
+		}
+		for _, fhs := range r.MultipartForm.File {
+			for _, fh := range fhs {
+				file, _ := fh.Open()
+				b, _ := ioutil.ReadAll(file)
+				file.Close()
+				files[fh.Filename] = string(b)
+			}
+		}
+		return meta, files
+	}
+	page0 := r.URL.Query().Get("page") == "" || r.URL.Query().Get("page") == "0"
+
+	path := r.URL.Path
+	switch {
+	case path == "/api/v1/flow-templates" && r.Method == "GET":
+		content := []predixinsights.FlowTemplate{}
+		for _, ft := range f.templates {
+			if name := r.URL.Query().Get("name"); page0 && (name == "" || name == ft.Name) {
+				content = append(content, *ft)
+			}
+		}
+		reply(200, map[string]interface{}{"content": content})
+	case path == "/api/v1/flow-templates" && r.Method == "POST":
+		meta, files := metadata()
+		ft := f.addTemplate(predixinsights.FlowTemplate{Name: meta["name"], Version: meta["version"], Description: meta["description"], Type: meta["type"], Tags: []string{}})
+		for name := range files {
+			ft.BlobPath = "templates/" + name
+		}
+		reply(201, ft)
+	case fakeFlowPath.MatchString(path):
+		m := fakeFlowPath.FindStringSubmatch(path)
+		fl, ok := f.flows[m[2]]
+		if !ok {
+			reply(404, nil)
+			return
+		}
+		if m[3] == "/tags" {
+			tags := []string{}
+			decode(&tags)
+			fl.Tags = []interface{}{}
+			for _, tag := range tags {
+				fl.Tags = append(fl.Tags, tag)
+			}
+			reply(200, fl)
+			return
+		}
+		var args predixinsights.EncapsulatedSparkArgs
+		decode(&args)
+		fl.SparkArgs = args.SparkArgs
+		reply(202, fl)
+	case fakeTemplatePath.MatchString(path):
+		m := fakeTemplatePath.FindStringSubmatch(path)
+		ft, ok := f.templates[m[1]]
+		if !ok {
+			reply(404, nil)
+			return
+		}
+		switch m[2] {
+		case "/tags":
+			decode(&ft.Tags)
+			reply(200, ft)
+		case "/flows":
+			var req predixinsights.FlowRequest
+			decode(&req)
+			reply(201, f.addFlow(ft, req.Name))
+		default:
+			var args predixinsights.EncapsulatedSparkArgs
+			decode(&args)
+			ft.SparkArgs = args.SparkArgs
+			reply(202, ft)
+		}
+	case path == "/api/v1/flows" && r.Method == "GET":
+		content := []predixinsights.Flow{}
+		for _, fl := range f.flows {
+			if page0 {
+				if ft, ok := f.templates[fl.FlowTemplate.ID]; ok {
+					fl.FlowTemplate.Name = ft.Name
+				}
+				content = append(content, *fl)
+			}
+		}
+		reply(200, map[string]interface{}{"content": content})
+	case fakeConfigPath.MatchString(path):
+		configs, ok := f.configs[fakeConfigPath.FindStringSubmatch(path)[1]]
+		if !ok {
+			reply(404, nil)
+			return
+		}
+		name := r.URL.Query().Get("file")
+		switch {
+		case r.Method == "GET" && name != "":
+			if !isJSONFileName(name) {
+				f.t.Errorf("%s was downloaded, the API only returns JSON config files", name)
+			}
+			w.Write([]byte(configs[name]))
+		case r.Method == "GET":
+			list := predixinsights.ListConfigFiles{}
+			for _, name := range sortedKeys(configs) {
+				list = append(list, struct {
+					FileName        string `json:"fileName"`
+					FileSize        int    `json:"fileSize"`
+					LastUpdatedTime int64  `json:"lastUpdatedTime"`
+					Directory       bool   `json:"directory"`
+				}{FileName: name, FileSize: len(configs[name])})
+			}
+			reply(200, list)
+		case r.Method == "POST":
+			_, files := metadata()
+			for name, content := range files {
+				configs[name] = content
+			}
+			reply(201, nil)
+		case r.Method == "DELETE":
+			delete(configs, name)
+			reply(204, nil)
+		}
+	case path == "/api/v1/dags" && r.Method == "GET":
+		content := []predixinsights.DAG{}
+		for _, d := range f.dags {
+			content = append(content, predixinsights.DAG{ID: d.ID, Name: d.Name, Type: d.Type, Deployed: d.Deployed})
+		}
+		reply(200, predixinsights.GetAllDAGsResponse{Content: content, Last: true})
+	case path == "/api/v1/dags" && r.Method == "POST":
+		meta, files := metadata()
+		for name, content := range files {
+			d := f.addDAG(meta["name"], meta["version"], meta["description"], meta["type"], name)
+			if m := fakeDAGFileContent.FindStringSubmatch(content); m != nil {
+				d.owner, d.interval = m[1], m[2]
+			}
+			reply(201, d.DAGResponse)
+		}
+	case fakeDAGStatusPath.MatchString(path):
+		// a DAG without an owner stands for one the scheduler has not picked up yet
+		d, ok := f.dags[fakeDAGStatusPath.FindStringSubmatch(path)[1]]
+		if !ok || d.owner == "" {
+			reply(404, nil)
+			return
+		}
+		reply(200, predixinsights.SingleDAGStatus{DagName: d.Name, Dags: []predixinsights.DAGStatuses{{DagName: d.Name, DagOwner: d.owner, ScheduleInterval: d.interval}}})
+	case fakeDAGPath.MatchString(path):
+		m := fakeDAGPath.FindStringSubmatch(path)
+		d, ok := f.dags[m[1]]
+		if !ok {
+			reply(404, nil)
+			return
+		}
+		if m[2] == "/deploy" {
+			d.Deployed = true
+			reply(202, nil)
+			return
+		}
+		reply(200, d)
+	case path == "/api/v1/dependencies/" && r.Method == "GET":
+		reply(200, predixinsights.DependenciesResponse{})
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		reply(404, nil)
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), os.FileMode(0644)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackupRestoreRoundTrip(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "pi-backup-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	artifactDir := filepath.Join(tmp, "artifacts")
+	os.MkdirAll(artifactDir, os.ModePerm)
+	writeTestFile(t, filepath.Join(artifactDir, "app.zip"), "zip")
+	writeTestFile(t, filepath.Join(artifactDir, "dag.py"), "owner={{.Owner}}\ninterval={{.Interval}}\n")
+	writeTestFile(t, filepath.Join(artifactDir, "c.yaml"), "a: 1\n")
+
+	source := newFakeTenant(t)
+	ft := source.addTemplate(predixinsights.FlowTemplate{Name: "T", Version: "1.0", Description: "template", Type: "SPARK_JAVA", Tags: []string{"team"}, BlobPath: "templates/app.zip", SparkArgs: predixinsights.SparkArguments{ClassName: "Main"}})
+	fl := source.addFlow(ft, "F")
+	fl.SparkArgs.NumExecutors = 2
+	fl.Tags = []interface{}{"team"}
+	source.configs[fl.ID] = map[string]string{"c.json": "{\n  \"a\": 1\n}\n", "c.yaml": "a: 1\n"}
+	d := source.addDAG("D", "2.0", "dag", "SPARK_PYTHON", "dag.py")
+	d.owner, d.interval, d.Deployed = "o", "@daily", true
+	sourceServer := httptest.NewServer(source)
+	defer sourceServer.Close()
+	target := newFakeTenant(t)
+	targetServer := httptest.NewServer(target)
+	defer targetServer.Close()
+
+	archive := filepath.Join(tmp, "backup.tar.gz")
+	sourceClient := &predixinsights.Client{APIHost: sourceServer.URL, TenantID: "source"}
+	index, err := createBackup(sourceClient, archive, artifactDir)
+	if err != nil {
+		t.Fatalf("backup err=%s", err.Error())
+	}
+	if len(index.Missing) > 0 {
+		t.Errorf("missing %v", index.Missing)
+	}
+	if index.APIHost != sourceServer.URL || index.TenantID != "source" {
+		t.Errorf("backup of %s %s, want %s source", index.APIHost, index.TenantID, sourceServer.URL)
+	}
+
+	dir := filepath.Join(tmp, "restore")
+	index, err = extractBackup(archive, dir)
+	if err != nil {
+		t.Fatalf("extract err=%s", err.Error())
+	}
+	targetClient := &predixinsights.Client{APIHost: targetServer.URL, TenantID: "target"}
+	failed, err := restoreBackup(targetClient, index, dir, false, "")
+	if err != nil {
+		t.Fatalf("restore err=%s", err.Error())
+	}
+	if len(failed) > 0 {
+		t.Errorf("not restored %v", failed)
+	}
+
+	want, _, err := exportManifest(sourceClient, exportSelector{}, filepath.Join(tmp, "source"))
+	if err != nil {
+		t.Fatalf("export source err=%s", err.Error())
+	}
+	got, _, err := exportManifest(targetClient, exportSelector{}, filepath.Join(tmp, "target"))
+	if err != nil {
+		t.Fatalf("export target err=%s", err.Error())
+	}
+	if !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		t.Errorf("restored\n%s\nwant\n%s", gotJSON, wantJSON)
+	}
+	for _, restored := range target.flows {
+		if configs := target.configs[restored.ID]; !reflect.DeepEqual(configs, source.configs[fl.ID]) {
+			t.Errorf("restored config files %v, want %v", configs, source.configs[fl.ID])
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// cacheTTLs is how long a cached response is served without revalidation, per resource
+var cacheTTLs = map[string]time.Duration{
+	"flow-templates": 5 * time.Minute,
+	"flows":          2 * time.Minute,
+	"instances":      10 * time.Second,
+	"dags":           5 * time.Minute,
+	"dependencies":   5 * time.Minute,
+}
+
+// cacheInvalidates lists the collections a change to a resource makes stale
+var cacheInvalidates = map[string][]string{
+	"flow-templates": []string{"flow-templates", "flows"},
+	"flows":          []string{"flows", "flow-templates"},
+	"instances":      []string{"instances"},
+	"dags":           []string{"dags"},
+	"dependencies":   []string{"dependencies"},
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Response Cache",
+	Long:  `Local cache of API read responses.`,
+}
+
+func init() {
+	RootCmd.AddCommand(cacheCmd)
+}
+
+var clearCacheCmd = &cobra.Command{
+	Use:     "clear",
+	Short:   "Clear the Response Cache",
+	Long:    `Remove all cached API responses.`,
+	Example: "  pi cache clear",
+	Run: func(cmd *cobra.Command, args []string) {
+		err := os.RemoveAll(cacheDir())
+		if err != nil {
+			fmt.Println("error clearing cache err=" + err.Error())
+			return
+		}
+		fmt.Println("Cache cleared.")
+	},
+}
+
+type cachedResponse struct {
+	Tenant     string      `json:"tenant"`
+	Resource   string      `json:"resource"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	StoredAt   time.Time   `json:"storedAt"`
+}
+
+// cacheTransport is a http.RoundTripper caching GET responses on disk, enabled with "cache": true in the config file
+type cacheTransport struct {
+	next http.RoundTripper
+	dir  string
+	ttls map[string]time.Duration
+}
+
+func cacheDir() string {
+	return filepath.Join(dir, "cache")
+}
+
+func cacheEnabled() bool {
+	return viper.GetBool("cache") && !viper.GetBool("no-cache")
+}
+
+// newCacheTransport applies cacheTTL overrides such as "flows=30s,instances=0s" from the config file
+func newCacheTransport(next http.RoundTripper) (*cacheTransport, error) {
+	ttls := map[string]time.Duration{}
+	for k, v := range cacheTTLs {
+		ttls[k] = v
+	}
+	if overrides := viper.GetString("cacheTTL"); overrides != "" {
+		for _, o := range strings.Split(overrides, ",") {
+			kv := strings.SplitN(strings.TrimSpace(o), "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("invalid cacheTTL entry '%s', expected resource=duration", o)
+			}
+			d, err := time.ParseDuration(kv[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid cacheTTL duration for %s err=%s", kv[0], err.Error())
+			}
+			ttls[kv[0]] = d
+		}
+	}
+	return &cacheTransport{next: next, dir: cacheDir(), ttls: ttls}, nil
+}
+
+// cacheResource returns the collection a request belongs to, e.g. flows for /api/v1/flows/ID/config
+func cacheResource(req *http.Request) string {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "api" {
+		return ""
+	}
+	return parts[2]
+}
+
+func (t *cacheTransport) key(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.URL.Host + "\n" + req.Header.Get("predix-zone-id") + "\n" + req.URL.RequestURI()))
+	return filepath.Join(t.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resource := cacheResource(req)
+	if resource == "" {
+		// never cache UAA tokens or anything outside the API
+		return t.next.RoundTrip(req)
+	}
+	if !strings.EqualFold(req.Method, "GET") {
+		res, err := t.next.RoundTrip(req)
+		if err == nil && res.StatusCode >= 200 && res.StatusCode < 300 {
+			t.invalidate(req, resource)
+		}
+		return res, err
+	}
+
+	path := t.key(req)
+	cached := t.load(path)
+	if cached != nil && time.Since(cached.StoredAt) < t.ttls[resource] {
+		return cached.response(req, "hit"), nil
+	}
+	if cached != nil && cached.Header.Get("ETag") != "" {
+		req.Header.Set("If-None-Match", cached.Header.Get("ETag"))
+	}
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotModified && cached != nil {
+		res.Body.Close()
+		cached.StoredAt = time.Now()
+		t.store(path, cached)
+		return cached.response(req, "revalidated"), nil
+	}
+	if res.StatusCode != http.StatusOK || (t.ttls[resource] <= 0 && res.Header.Get("ETag") == "") {
+		return res, nil
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	t.store(path, &cachedResponse{
+		Tenant:     req.Header.Get("predix-zone-id"),
+		Resource:   resource,
+		URL:        req.URL.String(),
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Body:       body,
+		StoredAt:   time.Now(),
+	})
+	return res, nil
+}
+
+func (t *cacheTransport) load(path string) *cachedResponse {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	c := &cachedResponse{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil
+	}
+	return c
+}
+
+// store is best effort, a cache that cannot be written only costs a request
+func (t *cacheTransport) store(path string, c *cachedResponse) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(t.dir, os.FileMode(0700)); err != nil {
+		return
+	}
+	ioutil.WriteFile(path, b, os.FileMode(0600))
+}
+
+// invalidate removes cached collections affected by a successful change
+func (t *cacheTransport) invalidate(req *http.Request, resource string) {
+	stale := append([]string{}, cacheInvalidates[resource]...)
+	if strings.HasSuffix(req.URL.Path, "/launch") || strings.HasSuffix(req.URL.Path, "/stop") {
+		stale = append(stale, "instances")
+	}
+	files, err := ioutil.ReadDir(t.dir)
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		path := filepath.Join(t.dir, f.Name())
+		c := t.load(path)
+		if c == nil || c.Tenant == req.Header.Get("predix-zone-id") && containsString(stale, c.Resource) {
+			os.Remove(path)
+		}
+	}
+}
+
+func (c *cachedResponse) response(req *http.Request, status string) *http.Response {
+	header := http.Header{}
+	for k, v := range c.Header {
+		header[k] = v
+	}
+	header.Set("X-Pi-Cache", status)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.StatusCode, http.StatusText(c.StatusCode)),
+		StatusCode:    c.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,496 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+	"github.com/spf13/cobra"
+)
+
+var flowConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Flow Config Files",
+	Long:  `Manage the config files of a Predix Insights Flow as a local directory.`,
+}
+
+func init() {
+	flowCmd.AddCommand(flowConfigCmd)
+}
+
+var syncConfigCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync a Directory of Config Files to a Flow",
+	Long: `Compare the files of a directory with the config files of a Predix Insights Flow, upload the new and changed files in one request
+and, with --prune, delete the config files missing from the directory once the upload succeeded. JSON files are compared by content, others can't be downloaded to compare and are always uploaded again.
+--include and --exclude take comma separated globs matched against file names, files they filter out are neither uploaded nor deleted.
+Subdirectories and hidden files are skipped. With --render the files are Go templates rendered with --vars, the environment, the tenant ID
+and secrets before they are compared and uploaded from memory, see pi flow config render. Files with a JSON Schema are validated
+first, see pi flow add-config-file, and nothing is synced when one does not match.`,
+	Example: "  pi flow config sync --flowID MY_FLOW_ID --dir ./conf\n  pi flow config sync --flowID MY_FLOW_ID --dir ./conf --include \"*.json,*.properties\" --exclude \"local-*\" --prune --plan\n  pi flow config sync --flowID MY_FLOW_ID --dir ./conf --render --vars envs/prod.yaml --secrets ~/.pi/secrets.yaml",
+	Run: func(cmd *cobra.Command, args []string) {
+		if syncConfigPI.V.GetBool("render") {
+			bypassCacheForSecrets()
+		}
+		client, err := login()
+		if err != nil {
+			fmt.Println("authentication error err=" + err.Error())
+			os.Exit(exitError)
+		}
+		err = getMissingRequiredParams(syncConfigPI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			os.Exit(exitError)
+		}
+		filter, err := newFileFilter(syncConfigPI.V.GetString("include"), syncConfigPI.V.GetString("exclude"))
+		if err != nil {
+			fmt.Println("invalid glob err=" + err.Error())
+			os.Exit(exitError)
+		}
+		var t *configTemplate
+		if syncConfigPI.V.GetBool("render") {
+			if t, err = newConfigTemplate(syncConfigPI.V.GetString("vars"), syncConfigPI.V.GetString("secrets"), false); err != nil {
+				fmt.Println("error " + err.Error())
+				os.Exit(exitError)
+			}
+		}
+		flowID := syncConfigPI.V.GetString("flowID")
+		changes, err := planConfigSync(client, flowID, syncConfigPI.V.GetString("dir"), filter, syncConfigPI.V.GetBool("prune"), t)
+		if err != nil {
+			fmt.Println("error comparing config files err=" + err.Error())
+			os.Exit(exitError)
+		}
+		schemas, err := schemasOfFlow(client, flowID, syncConfigPI.V.GetString("templateFilePath"))
+		if err != nil {
+			fmt.Println("error loading config file schemas err=" + err.Error())
+			os.Exit(exitError)
+		}
+		if err := schemas.validateUploads(uploads(changes)); err != nil {
+			fmt.Println("error validating config files err=" + err.Error())
+			os.Exit(exitError)
+		}
+		counts := map[string]int{}
+		for _, c := range changes {
+			counts[c.action]++
+			c.print()
+		}
+		summary := fmt.Sprintf("%d to upload, %d to update, %d to delete, %d unchanged, %d kept", counts[configUpload], counts[configUpdate], counts[configDelete], counts[configUnchanged], counts[configKeep])
+		if syncConfigPI.V.GetBool("plan") {
+			fmt.Printf("\nPlan: %s.\n", summary)
+			cleanup(syncConfigPI)
+			if counts[configUpload]+counts[configUpdate]+counts[configDelete] > 0 {
+				os.Exit(exitChanges)
+			}
+			return
+		}
+		if err := syncConfigFiles(client, flowID, changes); err != nil {
+			fmt.Println("error syncing config files err=" + err.Error())
+			os.Exit(exitError)
+		}
+		fmt.Printf("\nSync complete. %d uploaded, %d updated, %d deleted, %d unchanged, %d kept.\n", counts[configUpload], counts[configUpdate], counts[configDelete], counts[configUnchanged], counts[configKeep])
+		cleanup(syncConfigPI)
+	},
+}
+
+// actions of a config file sync
+const (
+	configUpload    = "upload"
+	configUpdate    = "update"
+	configDelete    = "delete"
+	configKeep      = "keep"
+	configUnchanged = "unchanged"
+)
+
+// configChange is the action a sync takes for one config file, path is the local file and contents the rendered one
+type configChange struct {
+	name, path string
+	action     string
+	contents   []byte
+}
+
+func (c configChange) print() {
+	switch c.action {
+	case configUpload:
+		fmt.Printf("+ %s\n", c.name)
+	case configUpdate:
+		fmt.Printf("~ %s\n", c.name)
+	case configDelete:
+		fmt.Printf("- %s\n", c.name)
+	case configKeep:
+		fmt.Printf("  %s is not in the directory, kept without --prune\n", c.name)
+	}
+}
+
+// fileFilter matches file names against comma separated include and exclude globs, an empty include matches all
+type fileFilter struct {
+	include, exclude []string
+}
+
+func newFileFilter(include, exclude string) (fileFilter, error) {
+	f := fileFilter{include: splitList(include), exclude: splitList(exclude)}
+	for _, pattern := range append(append([]string{}, f.include...), f.exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return f, fmt.Errorf("%q %s", pattern, err.Error())
+		}
+	}
+	return f, nil
+}
+
+func (f fileFilter) match(name string) bool {
+	matches := func(patterns []string) bool {
+		for _, p := range patterns {
+			if ok, _ := filepath.Match(p, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return (len(f.include) == 0 || matches(f.include)) && !matches(f.exclude)
+}
+
+// localConfigFiles returns the paths of the files of dir the filter matches by name
+func localConfigFiles(dir string, filter fileFilter) (map[string]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	local := map[string]string{}
+	for _, f := range files {
+		if f.IsDir() || strings.HasPrefix(f.Name(), ".") || !filter.match(f.Name()) {
+			continue
+		}
+		local[f.Name()] = filepath.Join(dir, f.Name())
+	}
+	return local, nil
+}
+
+// planConfigSync compares the files of dir, rendered when t is not nil, with the config files of a flow, both filtered, sorted by name
+func planConfigSync(client *predixinsights.Client, flowID, dir string, filter fileFilter, prune bool, t *configTemplate) ([]configChange, error) {
+	local, err := localConfigFiles(dir, filter)
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.ListConfigFilesByFlowID(flowID)
+	if err != nil {
+		return nil, err
+	}
+	sizes := map[string]int{}
+	for _, f := range list {
+		if !f.Directory && filter.match(f.FileName) {
+			sizes[f.FileName] = f.FileSize
+		}
+	}
+	changes := []configChange{}
+	for _, name := range sortedKeys(local) {
+		c := configChange{name: name, path: local[name], action: configUpload}
+		if t != nil {
+			if c.contents, err = t.render(c.path); err != nil {
+				return nil, err
+			}
+		}
+		if _, ok := sizes[name]; ok {
+			b := c.contents
+			if b == nil {
+				if b, err = ioutil.ReadFile(c.path); err != nil {
+					return nil, err
+				}
+			}
+			changed, err := configFileChanged(client, flowID, name, b)
+			if err != nil {
+				return nil, fmt.Errorf("%s err=%s", name, err.Error())
+			}
+			c.action = configUnchanged
+			if changed {
+				c.action = configUpdate
+			}
+		}
+		changes = append(changes, c)
+	}
+	for _, name := range sortedKeys(sizes) {
+		if _, ok := local[name]; ok {
+			continue
+		}
+		c := configChange{name: name, action: configKeep}
+		if prune {
+			c.action = configDelete
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// syncConfigFiles uploads the new and changed config files in one request, replacing a changed file takes deleting it
+// first. Pruned files are only deleted once the upload succeeded, so a failed sync loses no file that is not reported.
+func syncConfigFiles(client *predixinsights.Client, flowID string, changes []configChange) error {
+	replaced := []string{}
+	for _, c := range changes {
+		if c.action != configUpdate {
+			continue
+		}
+		if err := client.UpdateFlowByFlowIDDeleteConfigFile(flowID, c.name); err != nil {
+			return lostConfigFiles(fmt.Errorf("%s err=%s", c.name, err.Error()), replaced)
+		}
+		replaced = append(replaced, c.name)
+	}
+	upload, rendered := uploads(changes)
+	if len(upload) > 0 {
+		if err := addConfigFiles(client, flowID, upload, rendered); err != nil {
+			return lostConfigFiles(err, replaced)
+		}
+	}
+	for _, c := range changes {
+		if c.action == configDelete {
+			if err := client.UpdateFlowByFlowIDDeleteConfigFile(flowID, c.name); err != nil {
+				return fmt.Errorf("%s err=%s", c.name, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// lostConfigFiles names the changed config files that were deleted and not uploaded again in err
+func lostConfigFiles(err error, deleted []string) error {
+	if len(deleted) == 0 {
+		return err
+	}
+	return fmt.Errorf("%s, deleted and not uploaded again: %s", err.Error(), strings.Join(deleted, ", "))
+}
+
+// uploads returns the new and changed files of a sync and the rendered ones by name
+func uploads(changes []configChange) ([]predixinsights.FileDetails, map[string][]byte) {
+	upload := []predixinsights.FileDetails{}
+	rendered := map[string][]byte{}
+	for _, c := range changes {
+		if c.action == configUpload || c.action == configUpdate {
+			upload = append(upload, predixinsights.FileDetails{FileName: c.name, FileLocation: c.path})
+			if c.contents != nil {
+				rendered[c.name] = c.contents
+			}
+		}
+	}
+	return upload, rendered
+}
+
+var getConfigCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Download a Flow Config File",
+	Long: `Print a config file of a Predix Insights Flow as the flow runs with it, or write it to --output.
+Only JSON config files can be downloaded, the API returns them as objects with sorted keys.`,
+	Example: "  pi flow config get --flowID MY_FLOW_ID --file config.json\n  pi flow config get --flowID MY_FLOW_ID --file config.json -o conf/config.json",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := login()
+		if err != nil {
+			fmt.Println("authentication error err=" + err.Error())
+			return
+		}
+		err = getMissingRequiredParams(getConfigPI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			return
+		}
+		name := getConfigPI.V.GetString("file")
+		content, err := downloadConfigFile(client, getConfigPI.V.GetString("flowID"), name)
+		if err != nil {
+			fmt.Println("error downloading config file err=" + err.Error())
+			return
+		}
+		b, err := configFileJSON(content)
+		if err != nil {
+			fmt.Println("error printing config file err=" + err.Error())
+			return
+		}
+		if path := getConfigPI.V.GetString("output"); path != "" {
+			if err := ioutil.WriteFile(path, b, os.FileMode(0644)); err != nil {
+				fmt.Println("error writing config file err=" + err.Error())
+				return
+			}
+			fmt.Printf("Config file %s of flow %s written to %s.\n", name, getConfigPI.V.GetString("flowID"), path)
+		} else {
+			os.Stdout.Write(b)
+		}
+		cleanup(getConfigPI)
+	},
+}
+
+var pullConfigCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Download the Config Files of a Flow to a Directory",
+	Long: `Download the config files of a Predix Insights Flow to a directory, the reverse of pi flow config sync. Local files are overwritten.
+--include and --exclude take comma separated globs matched against file names. Config files that are not JSON cannot be downloaded and are skipped.
+The exit code is 1 when a download failed.`,
+	Example: "  pi flow config pull --flowID MY_FLOW_ID --dir ./conf\n  pi flow config pull --flowID MY_FLOW_ID --dir ./conf --include \"*.json\"",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := login()
+		if err != nil {
+			fmt.Println("authentication error err=" + err.Error())
+			os.Exit(exitError)
+		}
+		err = getMissingRequiredParams(pullConfigPI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			os.Exit(exitError)
+		}
+		filter, err := newFileFilter(pullConfigPI.V.GetString("include"), pullConfigPI.V.GetString("exclude"))
+		if err != nil {
+			fmt.Println("invalid glob err=" + err.Error())
+			os.Exit(exitError)
+		}
+		flowID, dir := pullConfigPI.V.GetString("flowID"), pullConfigPI.V.GetString("dir")
+		list, err := client.ListConfigFilesByFlowID(flowID)
+		if err != nil {
+			fmt.Println("error listing config files err=" + err.Error())
+			os.Exit(exitError)
+		}
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			fmt.Println("error creating directory err=" + err.Error())
+			os.Exit(exitError)
+		}
+		pulled, skipped, failed := 0, 0, 0
+		for _, cf := range list {
+			if cf.Directory || !filter.match(cf.FileName) {
+				continue
+			}
+			path := filepath.Join(dir, safeFileName(cf.FileName))
+			content, err := downloadConfigFile(client, flowID, cf.FileName)
+			if err == nil {
+				var b []byte
+				if b, err = configFileJSON(content); err == nil {
+					err = ioutil.WriteFile(path, b, os.FileMode(0644))
+				}
+			}
+			switch {
+			case err != nil && !isJSONFileName(cf.FileName):
+				skipped++
+				fmt.Printf("skipped %s, only JSON config files can be downloaded\n", cf.FileName)
+			case err != nil:
+				failed++
+				fmt.Printf("failed  %s err=%s\n", cf.FileName, err.Error())
+			default:
+				pulled++
+				fmt.Printf("pulled  %s -> %s\n", cf.FileName, path)
+			}
+		}
+		fmt.Printf("\nPull complete. %d pulled, %d skipped, %d failed.\n", pulled, skipped, failed)
+		cleanup(pullConfigPI)
+		if failed > 0 {
+			os.Exit(exitError)
+		}
+	},
+}
+
+var diffConfigCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Diff a Flow Config File with a Local File",
+	Long: `Compare a config file of a Predix Insights Flow with a local JSON or YAML file, --local defaults to the file name in the working directory.
+Both are rendered as JSON with sorted keys, --format unified prints a unified diff from the remote to the local file and --format semantic
+the changed values by JSON pointer. The exit code is 2 when they differ.`,
+	Example: "  pi flow config diff --flowID MY_FLOW_ID --file config.json --local conf/config.json\n  pi flow config diff --flowID MY_FLOW_ID --file config.json --format semantic",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := login()
+		if err != nil {
+			fmt.Println("authentication error err=" + err.Error())
+			os.Exit(exitError)
+		}
+		err = getMissingRequiredParams(diffConfigPI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			os.Exit(exitError)
+		}
+		format := diffConfigPI.V.GetString("format")
+		if format != "unified" && format != "semantic" {
+			fmt.Printf("invalid format %q, expected unified or semantic\n", format)
+			os.Exit(exitError)
+		}
+		flowID, name := diffConfigPI.V.GetString("flowID"), diffConfigPI.V.GetString("file")
+		path := orDefault(diffConfigPI.V.GetString("local"), name)
+		local, err := readConfigFile(path)
+		if err != nil {
+			fmt.Println("error reading local file err=" + err.Error())
+			os.Exit(exitError)
+		}
+		remote, err := downloadConfigFile(client, flowID, name)
+		if err != nil {
+			fmt.Println("error downloading config file err=" + err.Error())
+			os.Exit(exitError)
+		}
+		cleanup(diffConfigPI)
+		if format == "semantic" {
+			diffs := jsonDiff(remote, local)
+			if len(diffs) == 0 {
+				fmt.Printf("No differences between %s of flow %s and %s.\n", name, flowID, path)
+				return
+			}
+			for _, d := range diffs {
+				fmt.Println(d.String())
+			}
+			os.Exit(exitChanges)
+		}
+		r, err := configFileJSON(remote)
+		if err != nil {
+			fmt.Println("error rendering config file err=" + err.Error())
+			os.Exit(exitError)
+		}
+		l, err := configFileJSON(local)
+		if err != nil {
+			fmt.Println("error rendering local file err=" + err.Error())
+			os.Exit(exitError)
+		}
+		diff := unifiedDiff("flow "+flowID+" "+name, path, lines(r), lines(l))
+		if diff == "" {
+			fmt.Printf("No differences between %s of flow %s and %s.\n", name, flowID, path)
+			return
+		}
+		fmt.Print(diff)
+		os.Exit(exitChanges)
+	},
+}
+
+// downloadConfigFile returns a config file of a flow as the JSON object the API returns
+func downloadConfigFile(client *predixinsights.Client, flowID, name string) (map[string]interface{}, error) {
+	kvs, err := client.DownloadConfigFileByFlowID(flowID, name)
+	if err != nil {
+		return nil, err
+	}
+	content := map[string]interface{}{}
+	for _, kv := range kvs {
+		content[kv.Key] = kv.Value
+	}
+	return content, nil
+}
+
+// readConfigFile decodes a local JSON or YAML config file
+func readConfigFile(path string) (interface{}, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	j, err := yamlToJSON(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s is neither JSON nor YAML err=%s", path, err.Error())
+	}
+	var content interface{}
+	err = json.Unmarshal(j, &content)
+	return content, err
+}
+
+// configFileJSON renders config file content as indented JSON with sorted keys
+func configFileJSON(content interface{}) ([]byte, error) {
+	b, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+func isJSONFileName(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".json")
+}
+
+// lines splits b into lines without their line breaks
+func lines(b []byte) []string {
+	return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+}
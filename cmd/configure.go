@@ -38,18 +38,13 @@ var loginCmd = &cobra.Command{
 	},
 }
 
-func login() (predixinsights.Client, error) {
-	if loginPI.V.GetString("APIHost") == "" || loginPI.V.GetString("TenantID") == "" || loginPI.V.GetString("IssuerID") == "" || loginPI.V.GetString("ClientID") == "" || loginPI.V.GetString("ClientSecret") == "" {
-		return predixinsights.Client{}, errors.New("please configure the Predix Insights CLI\n\n$ pi configure -i")
+func login() (*predixinsights.Client, error) {
+	if viper.GetString("replay") == "" && (loginPI.V.GetString("APIHost") == "" || loginPI.V.GetString("TenantID") == "" || loginPI.V.GetString("IssuerID") == "" || loginPI.V.GetString("ClientID") == "" || loginPI.V.GetString("ClientSecret") == "") {
+		return nil, errors.New("please configure the Predix Insights CLI\n\n$ pi configure -i")
 	}
-	client := predixinsights.Client{APIHost: loginPI.V.GetString("APIHost"), TenantID: loginPI.V.GetString("TenantID"), IssuerID: loginPI.V.GetString("IssuerID"), ClientID: loginPI.V.GetString("ClientID"), ClientSecret: loginPI.V.GetString("ClientSecret"), Token: loginPI.V.GetString("Token")}
-
-	// set verbose mode for pi-go-sdk
-	client.Verbose = viper.GetBool("verbose")
-
-	err := client.RefreshAuthToken()
+	client, err := connect(loginPI.V)
 	if err != nil {
-		return predixinsights.Client{}, err
+		return nil, err
 	}
 
 	// save params
@@ -58,13 +53,39 @@ func login() (predixinsights.Client, error) {
 	return client, nil
 }
 
+// connect creates an authenticated client from the credentials in v
+func connect(v *viper.Viper) (*predixinsights.Client, error) {
+	client := &predixinsights.Client{APIHost: v.GetString("APIHost"), TenantID: v.GetString("TenantID"), IssuerID: v.GetString("IssuerID"), ClientID: v.GetString("ClientID"), ClientSecret: v.GetString("ClientSecret"), Token: v.GetString("Token")}
+
+	// replayed sessions are matched on path, so any host will do offline
+	if viper.GetString("replay") != "" && client.APIHost == "" {
+		client.APIHost = "http://replay"
+	}
+
+	err := installTransport()
+	if err != nil {
+		return nil, err
+	}
+
+	err = client.RefreshAuthToken()
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
 func cleanup(pi pi) {
 	// reset to default
 	pi.V.Set("tail", false)
-	pi.V.Set("verbose", false)
+	pi.V.Set("verbose", 0)
 	pi.V.Set("interactive", false)
 	pi.V.Set("containerlogsink", 1)
 
+	// a dry run changes nothing, the IDs it pretends to create included
+	if viper.GetBool("dry-run") {
+		return
+	}
+
 	// ensure dir exists
 	if _, err := os.Stat(filepath.Dir(viper.ConfigFileUsed())); os.IsNotExist(err) {
 		os.MkdirAll(filepath.Dir(viper.ConfigFileUsed()), os.ModePerm)
@@ -85,8 +106,8 @@ func cleanup(pi pi) {
 	if err != nil {
 		fmt.Println("error saving viper config file err= " + err.Error())
 	}
-	if viper.GetBool("verbose") {
-		fmt.Println("Saving config file:", pi.V.ConfigFileUsed())
+	if verbosity() > 0 {
+		fmt.Fprintln(os.Stderr, "Saving config file:", pi.V.ConfigFileUsed())
 	}
 }
 
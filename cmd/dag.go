@@ -74,7 +74,7 @@ var deleteDagCmd = &cobra.Command{
 			fmt.Println("failed to get required parameters err=" + err.Error())
 			return
 		}
-		if !deleteDagPI.V.GetBool("force") {
+		if !deleteDagPI.V.GetBool("force") && !viper.GetBool("dry-run") {
 			fmt.Printf("Really delete the DAG '%s'? ", deleteDagPI.V.GetString("dagName"))
 			if !askForConfirmation() {
 				return
@@ -108,7 +108,11 @@ var postDagCmd = &cobra.Command{
 			return
 		}
 		dt := &predixinsights.DAGTemplate{}
-		err = json.Unmarshal([]byte(postDagPI.V.GetString("dagTemplate")), dt)
+		err = decodeJSONFlag(postDagPI, "dagTemplate", dt)
+		if err != nil {
+			fmt.Println("failed to parse dagTemplate err=" + err.Error())
+			return
+		}
 		dag, err := client.PostDAG(postDagPI.V.GetString("dagName"), postDagPI.V.GetString("dagFileName"), postDagPI.V.GetString("dagFilePath"), postDagPI.V.GetString("dagVersion"), postDagPI.V.GetString("dagDesc"), postDagPI.V.GetString("dagFlowType"), *dt)
 		if err != nil {
 			fmt.Println("error posting dag err=" + err.Error())
@@ -140,7 +144,7 @@ var updateDagCmd = &cobra.Command{
 			return
 		}
 		dt := &predixinsights.DAGTemplate{}
-		err = json.Unmarshal([]byte(viper.GetString("dagTemplate")), dt)
+		err = decodeJSONFlag(updateDagPI, "dagTemplate", dt)
 		if err != nil {
 			fmt.Println("failed to parse dagTemplate err=" + err.Error())
 			return
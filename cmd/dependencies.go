@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 func init() {
@@ -136,7 +137,7 @@ var deleteDependencyCmd = &cobra.Command{
 			fmt.Println("failed to get required parameters err=" + err.Error())
 			return
 		}
-		if !deleteDependencyPI.V.GetBool("force") {
+		if !deleteDependencyPI.V.GetBool("force") && !viper.GetBool("dry-run") {
 			fmt.Printf("Really delete the Dependency '%s'? ", deleteDependencyPI.V.GetString("dependencyID"))
 			if !askForConfirmation() {
 				return
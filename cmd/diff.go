@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around the changes of a unified diff hunk
+const diffContext = 3
+
+// lineEdit is one line of a line diff, op is ' ' for a line in both, '-' for a removed and '+' for an added line
+type lineEdit struct {
+	op   byte
+	line string
+}
+
+// diffLines returns the shortest edit script turning a into b, Myers' algorithm
+func diffLines(a, b []string) []lineEdit {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	trace := [][]int{}
+search:
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int{}, v...))
+		for k := -d; k <= d; k += 2 {
+			x := v[offset+k-1] + 1
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+	edits := []lineEdit{}
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			edits = append(edits, lineEdit{' ', a[x-1]})
+			x, y = x-1, y-1
+		}
+		if x == prevX {
+			edits = append(edits, lineEdit{'+', b[y-1]})
+			y--
+		} else {
+			edits = append(edits, lineEdit{'-', a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		edits = append(edits, lineEdit{' ', a[x-1]})
+		x, y = x-1, y-1
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// unifiedDiff renders the changes from a to b as a unified diff, empty when they are equal
+func unifiedDiff(fromName, toName string, a, b []string) string {
+	edits := diffLines(a, b)
+	changes := []int{}
+	// aLine and bLine count the lines of a and b before each edit
+	aLine, bLine := make([]int, len(edits)+1), make([]int, len(edits)+1)
+	for i, e := range edits {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if e.op != '+' {
+			aLine[i+1]++
+		}
+		if e.op != '-' {
+			bLine[i+1]++
+		}
+		if e.op != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", fromName, toName)
+	for h := 0; h < len(changes); {
+		start := changes[h] - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := changes[h]
+		for h < len(changes) && changes[h]-end <= 2*diffContext {
+			end = changes[h]
+			h++
+		}
+		stop := end + diffContext + 1
+		if stop > len(edits) {
+			stop = len(edits)
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(aLine[start], aLine[stop]-aLine[start]), hunkRange(bLine[start], bLine[stop]-bLine[start]))
+		for _, e := range edits[start:stop] {
+			buf.WriteByte(e.op)
+			buf.WriteString(e.line)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.String()
+}
+
+// hunkRange formats the range of a hunk, the line before it when it is empty
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return strconv.Itoa(before) + ",0"
+	}
+	return strconv.Itoa(before+1) + "," + strconv.Itoa(count)
+}
+
+// jsonDiff compares two decoded JSON documents value by value, objects key by key and arrays index by index, the paths
+// are JSON pointers
+func jsonDiff(old, new interface{}) []fieldDiff {
+	o, n := map[string]string{}, map[string]string{}
+	flattenJSON(old, "", o)
+	flattenJSON(new, "", n)
+	diffs := []fieldDiff{}
+	for _, p := range sortedKeys(union(o, n)) {
+		ov, inOld := o[p]
+		nv, inNew := n[p]
+		path := orDefault(p, "/")
+		switch {
+		case !inOld:
+			diffs = append(diffs, added(path, nv))
+		case !inNew:
+			diffs = append(diffs, removed(path, ov))
+		case ov != nv:
+			diffs = append(diffs, changed(path, ov, nv))
+		}
+	}
+	return diffs
+}
+
+// flattenJSON adds the values of v that are neither objects nor arrays, or empty ones, to flat by their JSON pointer
+func flattenJSON(v interface{}, pointer string, flat map[string]string) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if len(t) == 0 {
+			break
+		}
+		for k, e := range t {
+			flattenJSON(e, pointer+"/"+escapePointer(k), flat)
+		}
+		return
+	case []interface{}:
+		if len(t) == 0 {
+			break
+		}
+		for i, e := range t {
+			flattenJSON(e, pointer+"/"+strconv.Itoa(i), flat)
+		}
+		return
+	}
+	flat[pointer] = jsonString(v)
+}
+
+// escapePointer escapes a key for a JSON pointer, RFC 6901
+func escapePointer(key string) string {
+	return strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+}
+
+func union(a, b map[string]string) map[string]bool {
+	keys := map[string]bool{}
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	return keys
+}
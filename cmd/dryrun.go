@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// dryRunID is the ID of everything a dry run pretends to create, later changes to it are printed with it
+const dryRunID = "dry-run"
+
+// dryRunResponses are the statuses the SDK expects for changes, by method and path, the first match wins
+var dryRunResponses = []struct {
+	method string
+	path   *regexp.Regexp
+	status int
+	body   string
+}{
+	{"DELETE", regexp.MustCompile(`.`), http.StatusNoContent, ""},
+	{"POST", regexp.MustCompile(`/dependencies/undeploy/`), http.StatusNoContent, ""},
+	{"POST", regexp.MustCompile(`/dependencies/deploy/`), http.StatusOK, ""},
+	{"POST", regexp.MustCompile(`/dependencies/$`), http.StatusCreated, "[]"},
+	{"POST", regexp.MustCompile(`/tags$`), http.StatusOK, ""},
+	{"POST", regexp.MustCompile(`/(launch|stop|deploy)$`), http.StatusAccepted, ""},
+	{"POST", regexp.MustCompile(`/api/v1/dags/[^/]+$`), http.StatusAccepted, ""},
+	{"POST", regexp.MustCompile(`/api/v1/flow-templates/[^/]+(/flows/[^/]+)?$`), http.StatusAccepted, ""},
+	{"POST", regexp.MustCompile(`/api/v1/flows/[^/]+$`), http.StatusOK, ""},
+	{"POST", regexp.MustCompile(`.`), http.StatusCreated, ""},
+}
+
+// dryRunTransport is a http.RoundTripper letting reads through and printing, instead of sending, changes. Changes are
+// answered as the SDK expects, with dryRunID for created resources, so commands go on to print the changes after them.
+// Commands skip reads of what a dry run created, reads of dryRunID that get here anyway fail without being sent.
+type dryRunTransport struct {
+	next http.RoundTripper
+}
+
+func (t *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.EqualFold(req.Method, "GET") || strings.EqualFold(req.Method, "HEAD") {
+		for _, segment := range strings.Split(req.URL.Path, "/") {
+			if segment == dryRunID {
+				return nil, fmt.Errorf("dry run, not sent: %s %s reads a resource the dry run did not create", strings.ToUpper(req.Method), redactURL(req.URL.String()))
+			}
+		}
+		return t.next.RoundTrip(req)
+	}
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("Dry run, not sent:")
+	printDryRunRequest(req, body)
+	fmt.Println()
+	return dryRunResponse(req), nil
+}
+
+// dryRunCreated reports whether id is the ID of a resource a dry run pretended to create, which cannot be read
+func dryRunCreated(id string) bool {
+	return id == dryRunID && viper.GetBool("dry-run")
+}
+
+// dryRunResponse pretends req succeeded
+func dryRunResponse(req *http.Request) *http.Response {
+	status, body := http.StatusOK, ""
+	for _, r := range dryRunResponses {
+		if strings.EqualFold(req.Method, r.method) && r.path.MatchString(req.URL.Path) {
+			status, body = r.status, r.body
+			break
+		}
+	}
+	if body == "" && status != http.StatusNoContent {
+		body = fmt.Sprintf(`{"id": %q, "name": %q}`, dryRunID, dryRunID)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func printDryRunRequest(req *http.Request, body []byte) {
+	fmt.Printf("%s %s\n", strings.ToUpper(req.Method), redactURL(req.URL.String()))
+	h := redactHeader(req.Header)
+	keys := []string{}
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("  %s: %s\n", k, strings.Join(h[k], ", "))
+	}
+	if len(body) == 0 {
+		fmt.Println("  (no body)")
+		return
+	}
+	parts, err := multipartSummary(req.Header.Get("Content-Type"), body)
+	if err != nil {
+		fmt.Printf("Body (%d bytes):\n", len(body))
+		redacted := redactBody(body)
+		if buff, err := jsonBeautify(redacted); err == nil {
+			fmt.Printf("%s\n", buff.Bytes())
+		} else {
+			fmt.Printf("%s\n", redacted)
+		}
+		return
+	}
+	fmt.Printf("Multipart body (%d bytes):\n", len(body))
+	for _, p := range parts {
+		if p.fileName != "" {
+			fmt.Printf("  %s: file %s (%d bytes)\n", p.name, p.fileName, p.size)
+		} else {
+			fmt.Printf("  %s: %s\n", p.name, redactBody(p.value))
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+	"github.com/spf13/cobra"
+)
+
+// editErrorPrefix marks the lines annotating the last validation error at the top of the edited file
+const editErrorPrefix = "# error: "
+
+var editFlowCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit a Flow in $EDITOR",
+	Long: `Open the spark arguments and tags of a Predix Insights Flow as YAML in $VISUAL or $EDITOR, defaulting to vi.
+On save the document is validated, including the policy, the changes are printed and only the changed parts are updated.
+An invalid document is reopened with the error at the top, saving it unchanged or emptying it cancels the edit.`,
+	Example: "  pi flow edit --flowID MY_FLOW_ID\n  EDITOR=\"code --wait\" pi flow edit --flowID MY_FLOW_ID",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := login()
+		if err != nil {
+			fmt.Println("authentication error err=" + err.Error())
+			return
+		}
+		err = getMissingRequiredParams(editFlowPI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			return
+		}
+		flow, err := findFlow(client, editFlowPI.V.GetString("flowID"))
+		if err != nil {
+			fmt.Println("error getting flow err=" + err.Error())
+			return
+		}
+		if flow.FlowTemplate.ID == "" {
+			fmt.Printf("error flow '%s' is a direct flow, its spark arguments and tags cannot be updated\n", flow.Name)
+			return
+		}
+		err = edit(editTarget{
+			kind:     "flow",
+			name:     flow.Name,
+			id:       flow.ID,
+			flowType: flow.Type,
+			args:     flow.SparkArgs,
+			tags:     flowTags(flow.Tags),
+			saveArgs: func(args predixinsights.SparkArguments) error {
+				return client.UpdateFlowChangeSparkArguments(flow.FlowTemplate.ID, flow.ID, predixinsights.EncapsulatedSparkArgs{SparkArgs: args})
+			},
+			saveTags: func(tags predixinsights.TagsArray) error {
+				_, err := client.SaveTagsForFlow(flow.FlowTemplate.ID, flow.ID, tags)
+				return err
+			},
+		})
+		if err != nil {
+			fmt.Println("error editing flow err=" + err.Error())
+			return
+		}
+		cleanup(editFlowPI)
+	},
+}
+
+var editFlowTemplateCmd = &cobra.Command{
+	Use:     "edit",
+	Short:   "Edit a Flow Template in $EDITOR",
+	Long:    `Open the spark arguments and tags of a Predix Insights Flow Template as YAML in $VISUAL or $EDITOR, like pi flow edit.`,
+	Example: "  pi flow-template edit --flowTemplateID MY_FLOW_TEMPLATE_ID",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := login()
+		if err != nil {
+			fmt.Println("authentication error err=" + err.Error())
+			return
+		}
+		err = getMissingRequiredParams(editFlowTemplatePI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			return
+		}
+		template, err := client.GetFlowTemplate(editFlowTemplatePI.V.GetString("flowTemplateID"))
+		if err != nil {
+			fmt.Println("error getting flow template err=" + err.Error())
+			return
+		}
+		err = edit(editTarget{
+			kind:     "flow template",
+			name:     template.Name,
+			id:       template.ID,
+			flowType: template.Type,
+			args:     template.SparkArgs,
+			tags:     template.Tags,
+			saveArgs: func(args predixinsights.SparkArguments) error {
+				return client.UpdateFlowTemplateByFlowTemplateIDChangeSparkArguments(template.ID, predixinsights.EncapsulatedSparkArgs{SparkArgs: args})
+			},
+			saveTags: func(tags predixinsights.TagsArray) error {
+				_, err := client.SaveTagsForFlowTemplate(template.ID, tags)
+				return err
+			},
+		})
+		if err != nil {
+			fmt.Println("error editing flow template err=" + err.Error())
+			return
+		}
+		cleanup(editFlowTemplatePI)
+	},
+}
+
+// editDocument is the document opened in the editor
+type editDocument struct {
+	SparkArgs predixinsights.SparkArguments `json:"sparkArguments"`
+	Tags      []string                      `json:"tags"`
+}
+
+// editTarget is the edited flow or flow template and how its changes are saved
+type editTarget struct {
+	kind, name, id string
+	flowType       string
+	args           predixinsights.SparkArguments
+	tags           []string
+	saveArgs       func(predixinsights.SparkArguments) error
+	saveTags       func(predixinsights.TagsArray) error
+}
+
+// edit runs the editor until the document is valid, then saves what changed. Tags reserved for the CLI are not
+// shown and kept as they are.
+func edit(t editTarget) error {
+	b, err := json.Marshal(editDocument{SparkArgs: t.args, Tags: userTags(t.tags)})
+	if err != nil {
+		return err
+	}
+	y, err := jsonToYAML(b)
+	if err != nil {
+		return err
+	}
+	header := fmt.Sprintf("# Editing %s %s (%s).\n# Lines starting with # are ignored, an empty file cancels the edit. Tags starting with %s are kept by pi.\n", t.kind, t.name, t.id, reservedTagPrefix)
+	original := append([]byte(header), y...)
+
+	f, err := ioutil.TempFile("", "pi-edit-")
+	if err != nil {
+		return err
+	}
+	path := f.Name()
+	f.Close()
+	content, lastErr := original, error(nil)
+	updated := editDocument{}
+	for {
+		if err := ioutil.WriteFile(path, content, 0600); err != nil {
+			return err
+		}
+		if err := runEditor(path); err != nil {
+			return fmt.Errorf("%s, your edits are kept in %s", err.Error(), path)
+		}
+		edited, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if len(withoutComments(edited)) == 0 || bytes.Equal(edited, original) {
+			os.Remove(path)
+			fmt.Println("Edit cancelled, no changes made.")
+			return nil
+		}
+		if lastErr != nil && bytes.Equal(edited, content) {
+			return fmt.Errorf("%s, your edits are kept in %s", lastErr.Error(), path)
+		}
+		updated = editDocument{}
+		lastErr = decodeDocument(edited, &updated)
+		if lastErr == nil {
+			lastErr = validateEdit(updated)
+		}
+		if lastErr == nil {
+			lastErr = enforce(t.kind+" "+t.name, t.flowType, &updated.SparkArgs, updated.Tags)
+		}
+		if lastErr == nil {
+			break
+		}
+		content = annotateEditError(edited, lastErr)
+	}
+	os.Remove(path)
+
+	if updated.Tags == nil {
+		updated.Tags = []string{}
+	}
+	argDiffs := sparkArgsDiff(t.args, updated.SparkArgs)
+	tagDiffs := tagsDiff(userTags(t.tags), updated.Tags)
+	if len(argDiffs) == 0 && len(tagDiffs) == 0 {
+		fmt.Println("Edit cancelled, no changes made.")
+		return nil
+	}
+	fmt.Printf("~ %s %s (%s)\n", t.kind, t.name, t.id)
+	for _, d := range append(argDiffs, tagDiffs...) {
+		fmt.Println("    " + d.String())
+	}
+	if len(argDiffs) > 0 {
+		if err := t.saveArgs(updated.SparkArgs); err != nil {
+			return err
+		}
+	}
+	if len(tagDiffs) > 0 {
+		if err := t.saveTags(mergeTags(updated.Tags, t.tags, "")); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Successfully updated %s '%s'\n", t.kind, t.id)
+	return nil
+}
+
+// validateEdit checks what the document decoding does not
+func validateEdit(doc editDocument) error {
+	for _, tag := range doc.Tags {
+		if strings.HasPrefix(tag, reservedTagPrefix) {
+			return fmt.Errorf("tag %s: tags starting with %s are reserved for pi", tag, reservedTagPrefix)
+		}
+	}
+	return nil
+}
+
+// annotateEditError replaces the error lines at the top of edited with err
+func annotateEditError(edited []byte, err error) []byte {
+	lines := strings.Split(string(edited), "\n")
+	for len(lines) > 0 && strings.HasPrefix(lines[0], editErrorPrefix) {
+		lines = lines[1:]
+	}
+	annotated := []string{}
+	for _, l := range strings.Split(err.Error(), "\n") {
+		annotated = append(annotated, editErrorPrefix+l)
+	}
+	return []byte(strings.Join(append(annotated, lines...), "\n"))
+}
+
+// withoutComments returns the lines of b that are neither blank nor comments
+func withoutComments(b []byte) []byte {
+	kept := []string{}
+	for _, l := range strings.Split(string(b), "\n") {
+		if t := strings.TrimSpace(l); t != "" && !strings.HasPrefix(t, "#") {
+			kept = append(kept, l)
+		}
+	}
+	return []byte(strings.Join(kept, "\n"))
+}
+
+// runEditor opens path in $VISUAL or $EDITOR, which may include arguments, or vi
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		return errors.New("no editor")
+	}
+	c := exec.Command(args[0], append(args[1:], path)...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("editor %s failed err=%s", editor, err.Error())
+	}
+	return nil
+}
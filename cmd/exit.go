@@ -0,0 +1,15 @@
+package cmd
+
+// Exit codes shared by the commands that document them, scripts rely on them staying the same
+const (
+	// exitError is returned when a command fails
+	exitError = 1
+	// exitChanges is returned by --plan and diffs when changes are pending
+	exitChanges = 2
+	// exitInstanceFailed is returned when an instance finishes with any status but SUCCEEDED or KILLED
+	exitInstanceFailed = 3
+	// exitInstanceKilled is returned when an instance is killed
+	exitInstanceKilled = 4
+	// exitWaitTimeout is returned when an instance is still running after --timeout
+	exitWaitTimeout = 5
+)
@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+	"github.com/spf13/cobra"
+)
+
+// maxTemplatePages bounds the pages of flow templates read by export
+const maxTemplatePages = 100
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export Resources to Manifests",
+	Long: `Write flow templates, flows with their config files, DAG metadata and dependency metadata to a directory of YAML manifests.
+Without --name, --id or --tag every resource is exported. The directory can be applied again with pi apply -f.`,
+	Example: "  pi export --dir pi-export\n  pi export --dir pi-export --tag prod\n  pi export --dir pi-export --name MY_FLOW_NAME,MY_FLOW_TEMPLATE_NAME",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := login()
+		if err != nil {
+			fmt.Println("authentication error err=" + err.Error())
+			return
+		}
+		err = getMissingRequiredParams(exportPI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			return
+		}
+		sel := exportSelector{
+			names: splitList(exportPI.V.GetString("name")),
+			ids:   splitList(exportPI.V.GetString("id")),
+			tags:  splitList(exportPI.V.GetString("tag")),
+		}
+		out := exportPI.V.GetString("dir")
+		m, _, err := exportManifest(client, sel, out)
+		if err != nil {
+			fmt.Println("error exporting resources err=" + err.Error())
+			return
+		}
+		fmt.Printf("Exported %d flow template(s), %d flow(s), %d DAG(s) and %d dependency(ies) to %s\n", len(m.FlowTemplates), len(m.Flows), len(m.DAGs), len(m.Dependencies), out)
+	},
+}
+
+// exportSelector matches resources by name, ID or tag, an empty selector matches everything
+type exportSelector struct {
+	names []string
+	ids   []string
+	tags  []string
+}
+
+func (s exportSelector) match(name, id string, tags []string) bool {
+	if len(s.names) == 0 && len(s.ids) == 0 && len(s.tags) == 0 {
+		return true
+	}
+	if containsString(s.names, name) || containsString(s.ids, id) {
+		return true
+	}
+	for _, t := range tags {
+		if containsString(s.tags, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(s string) []string {
+	list := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// exportManifest writes one manifest per selected resource to dir, it returns everything it exported
+// and the IDs of the exported resources keyed by kind/name, flows by flow/<template>/<name>. Direct flows are skipped,
+// manifests only create flows from flow templates.
+func exportManifest(client *predixinsights.Client, sel exportSelector, dir string) (*manifest, map[string]string, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, nil, err
+	}
+	exported := &manifest{}
+	ids := map[string]string{}
+
+	templates, err := client.GetAllFlowTemplatesByPage(maxTemplatePages)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	for _, ft := range templates {
+		if !sel.match(ft.Name, ft.ID, ft.Tags) {
+			continue
+		}
+		sparkArgs := ft.SparkArgs
+		mt := manifestFlowTemplate{
+			Name:        ft.Name,
+			Version:     ft.Version,
+			Type:        ft.Type,
+			Description: ft.Description,
+			File:        baseName(ft.BlobPath),
+			SparkArgs:   &sparkArgs,
+			Tags:        userTags(ft.Tags),
+		}
+		header := fmt.Sprintf("Flow template %s exported by pi export.\nThe API does not return artifacts, place %s next to this file before applying.", ft.Name, mt.File)
+		if err := writeManifest(dir, "flow-template", ft.Name, header, &manifest{FlowTemplates: []manifestFlowTemplate{mt}}); err != nil {
+			return nil, nil, err
+		}
+		exported.FlowTemplates = append(exported.FlowTemplates, mt)
+		ids["flow-template/"+ft.Name] = ft.ID
+	}
+
+	flows, err := client.GetAllFlows(maxFlowPages)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(flows, func(i, j int) bool { return flows[i].Name < flows[j].Name })
+	for _, f := range flows {
+		if !sel.match(f.Name, f.ID, flowTags(f.Tags)) {
+			continue
+		}
+		if f.FlowTemplate.ID == "" {
+			fmt.Fprintf(os.Stderr, "warning: direct flow %s was not exported, manifests only create flows from flow templates\n", f.Name)
+			continue
+		}
+		key := flowKey(f.FlowTemplate.Name, f.Name)
+		sparkArgs := f.SparkArgs
+		mf := manifestFlow{
+			Name:      f.Name,
+			Template:  f.FlowTemplate.Name,
+			SparkArgs: &sparkArgs,
+			Tags:      userTags(flowTags(f.Tags)),
+		}
+		header := fmt.Sprintf("Flow %s of flow template %s exported by pi export.", f.Name, f.FlowTemplate.Name)
+		configFiles, notDownloaded, err := exportConfigFiles(client, f, dir)
+		if err != nil {
+			// without every config file the set would be pruned on apply, so it is left unmanaged
+			fmt.Fprintf(os.Stderr, "warning: config files of flow %s were not exported err=%s\n", f.Name, err.Error())
+			header += "\nConfig files could not be exported and are left untouched by pi apply."
+		} else {
+			mf.ConfigFiles = configFiles
+		}
+		if len(notDownloaded) > 0 {
+			header += fmt.Sprintf("\nThe API only returns JSON config files, place %s in %s before applying.", strings.Join(notDownloaded, ", "), configFilesDir(f))
+		}
+		if err := writeManifest(dir, "flow", key, header, &manifest{Flows: []manifestFlow{mf}}); err != nil {
+			return nil, nil, err
+		}
+		exported.Flows = append(exported.Flows, mf)
+		ids["flow/"+key] = f.ID
+	}
+
+	dags, err := client.GetAllDAGs()
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(dags.Content, func(i, j int) bool { return dags.Content[i].Name < dags.Content[j].Name })
+	for _, d := range dags.Content {
+		if !sel.match(d.Name, d.ID, nil) {
+			continue
+		}
+		md, err := exportDAG(client, d)
+		if err != nil {
+			return nil, nil, err
+		}
+		header := fmt.Sprintf("DAG %s exported by pi export.\nThe API does not return the DAG file, place %s next to this file before applying.", d.Name, md.File)
+		if md.File == "" {
+			header = fmt.Sprintf("DAG %s exported by pi export.\nThe API does not return the DAG file, fill in file and place it next to this file before applying.", d.Name)
+		}
+		if md.Template.FlowName == "" {
+			header += "\nThe API does not return the flow name the DAG file was rendered with, fill in template.flowName if the file uses it."
+		}
+		if err := writeManifest(dir, "dag", d.Name, header, &manifest{DAGs: []manifestDAG{md}}); err != nil {
+			return nil, nil, err
+		}
+		exported.DAGs = append(exported.DAGs, md)
+		ids["dag/"+d.Name] = d.ID
+	}
+
+	deps, err := client.GetAllDependencies()
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(deps.Content, func(i, j int) bool { return deps.Content[i].Name < deps.Content[j].Name })
+	for _, d := range deps.Content {
+		if !sel.match(d.Name, d.ID, nil) {
+			continue
+		}
+		md := manifestDependency{Name: d.Name, Type: d.Type, File: d.Name, Deploy: d.Deployed}
+		header := fmt.Sprintf("Dependency %s exported by pi export.\nThe API does not return dependency files, place %s next to this file before applying.", d.Name, d.Name)
+		if err := writeManifest(dir, "dependency", d.Name, header, &manifest{Dependencies: []manifestDependency{md}}); err != nil {
+			return nil, nil, err
+		}
+		exported.Dependencies = append(exported.Dependencies, md)
+		ids["dependency/"+d.Name] = d.ID
+	}
+	return exported, ids, nil
+}
+
+// exportDAG returns the manifest of a DAG, without the template values when the DAG has no status yet
+func exportDAG(client *predixinsights.Client, d predixinsights.DAG) (manifestDAG, error) {
+	md, statusErr, err := readDAG(client, d)
+	if statusErr != nil {
+		fmt.Fprintf(os.Stderr, "warning: the template values of DAG %s were not exported err=%s\n", d.Name, statusErr.Error())
+	}
+	return md, err
+}
+
+// readDAG returns a DAG as a manifest. The SDK does not decode the version of a DAG, and the owner and schedule
+// interval the DAG file was rendered with are only in the status of the DAG, which fails with statusErr for a DAG the
+// scheduler has not picked up yet.
+func readDAG(client *predixinsights.Client, d predixinsights.DAG) (md manifestDAG, statusErr, err error) {
+	var dag struct {
+		predixinsights.DAGResponse
+		Version string `json:"version"`
+	}
+	if err := getJSON(client, "/api/v1/dags/"+d.Name, &dag); err != nil {
+		return manifestDAG{}, nil, fmt.Errorf("dag %s err=%s", d.Name, err.Error())
+	}
+	description, _ := dag.Description.(string)
+	md = manifestDAG{Name: d.Name, Version: dag.Version, Type: d.Type, Description: description, File: baseName(dag.BlobPath), Deploy: d.Deployed}
+	status, err := client.GetDAGStatusByDAGName(d.Name)
+	if err != nil {
+		return md, err, nil
+	}
+	for _, s := range status.Dags {
+		md.Template.Owner, md.Template.Interval = s.DagOwner, s.ScheduleInterval
+	}
+	return md, nil, nil
+}
+
+// configFilesDir is the directory of dir the config files of a flow are exported to, flow names are unique per flow
+// template only
+func configFilesDir(f predixinsights.Flow) string {
+	return filepath.Join("config", safeFileName(f.FlowTemplate.Name), safeFileName(f.Name))
+}
+
+// exportConfigFiles downloads the config files of a flow to config/<template>/<flow>/ in dir. The API only returns JSON config
+// files, the others are listed in the manifest all the same, so apply does not delete them, and returned by name and
+// size.
+func exportConfigFiles(client *predixinsights.Client, f predixinsights.Flow, dir string) ([]manifestConfigFile, []string, error) {
+	list, err := client.ListConfigFilesByFlowID(f.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	configFiles, notDownloaded := []manifestConfigFile{}, []string{}
+	rel := configFilesDir(f)
+	for _, cf := range list {
+		if cf.Directory {
+			continue
+		}
+		configFiles = append(configFiles, manifestConfigFile{Name: cf.FileName, File: filepath.Join(rel, safeFileName(cf.FileName))})
+		if !isJSONFileName(cf.FileName) {
+			notDownloaded = append(notDownloaded, fmt.Sprintf("%s (%d bytes)", cf.FileName, cf.FileSize))
+			continue
+		}
+		content, err := downloadConfigFile(client, f.ID, cf.FileName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s err=%s", cf.FileName, err.Error())
+		}
+		b, err := configFileJSON(content)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := os.MkdirAll(filepath.Join(dir, rel), os.ModePerm); err != nil {
+			return nil, nil, err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, rel, safeFileName(cf.FileName)), b, os.FileMode(0644)); err != nil {
+			return nil, nil, err
+		}
+	}
+	return configFiles, notDownloaded, nil
+}
+
+// writeManifest writes m as <kind>-<name>.yaml with header as a comment
+func writeManifest(dir, kind, name, header string, m *manifest) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	y, err := jsonToYAML(b)
+	if err != nil {
+		return err
+	}
+	comment := "# " + strings.Replace(header, "\n", "\n# ", -1) + "\n"
+	path := filepath.Join(dir, kind+"-"+safeFileName(name)+".yaml")
+	return ioutil.WriteFile(path, append([]byte(comment), y...), os.FileMode(0644))
+}
+
+// baseName returns the file name of a blob path, empty when the path is
+func baseName(blobPath string) string {
+	if blobPath == "" {
+		return ""
+	}
+	return filepath.Base(blobPath)
+}
+
+func safeFileName(name string) string {
+	return unsafeFileChars.ReplaceAllString(name, "_")
+}
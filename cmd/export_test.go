@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+)
+
+func TestExportManifestFlowKeys(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "pi-export-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	tenant := newFakeTenant(t)
+	a := tenant.addTemplate(predixinsights.FlowTemplate{Name: "A", Version: "1", Type: "SPARK_JAVA", BlobPath: "templates/a.zip"})
+	b := tenant.addTemplate(predixinsights.FlowTemplate{Name: "B", Version: "1", Type: "SPARK_JAVA", BlobPath: "templates/b.zip"})
+	fa := tenant.addFlow(a, "x")
+	tenant.configs[fa.ID] = map[string]string{"c.json": `{"from": "A"}`}
+	fb := tenant.addFlow(b, "x")
+	tenant.configs[fb.ID] = map[string]string{"c.json": `{"from": "B"}`}
+	direct := tenant.addFlow(a, "direct")
+	direct.FlowTemplate = predixinsights.FlowTemplate{}
+	server := httptest.NewServer(tenant)
+	defer server.Close()
+
+	dir := filepath.Join(tmp, "export")
+	_, ids, err := exportManifest(&predixinsights.Client{APIHost: server.URL}, exportSelector{}, dir)
+	if err != nil {
+		t.Fatalf("err=%s", err.Error())
+	}
+	want := map[string]string{"flow-template/A": a.ID, "flow-template/B": b.ID, "flow/A/x": fa.ID, "flow/B/x": fb.ID}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("got ids %v, want %v", ids, want)
+	}
+
+	// the export is applied again as a whole, same named flows of different templates included
+	writeTestFile(t, filepath.Join(dir, "a.zip"), "a")
+	writeTestFile(t, filepath.Join(dir, "b.zip"), "b")
+	m, err := loadManifest(dir)
+	if err != nil {
+		t.Fatalf("reading the export err=%s", err.Error())
+	}
+	got := map[string]string{}
+	for _, f := range m.Flows {
+		for _, cf := range f.ConfigFiles {
+			b, err := ioutil.ReadFile(cf.File)
+			if err != nil {
+				t.Fatalf("%s err=%s", cf.File, err.Error())
+			}
+			got[flowKey(f.Template, f.Name)+"/"+cf.Name] = string(b)
+		}
+	}
+	wantFiles := map[string]string{"A/x/c.json": "{\n  \"from\": \"A\"\n}\n", "B/x/c.json": "{\n  \"from\": \"B\"\n}\n"}
+	if !reflect.DeepEqual(got, wantFiles) {
+		t.Errorf("got config files %v, want %v", got, wantFiles)
+	}
+}
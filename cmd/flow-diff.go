@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+	"github.com/spf13/cobra"
+)
+
+var diffFlowCmd = &cobra.Command{
+	Use:   "diff FLOW_ID [FLOW_ID]",
+	Short: "Compare Two Flows or a Flow and its Template",
+	Long: `Compare two Predix Insights Flows, or with --against-template a flow and its flow template: the type and template version,
+the spark arguments field by field and maps like confs entry by entry, the tags, the config files by name and the JSON ones by value,
+and the status of the latest instance. Flow templates have neither config files nor instances.
+--format side-by-side prints the differing values in columns, --format json the differences with JSON pointers into the config files.
+The exit code is 2 when they differ.`,
+	Example: "  pi flow diff MY_FLOW_ID MY_OTHER_FLOW_ID\n  pi flow diff MY_FLOW_ID --against-template\n  pi flow diff MY_FLOW_ID MY_OTHER_FLOW_ID --format json",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := login()
+		if err != nil {
+			fmt.Println("authentication error err=" + err.Error())
+			os.Exit(exitError)
+		}
+		err = getMissingRequiredParams(diffFlowPI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			os.Exit(exitError)
+		}
+		withTemplate := diffFlowPI.V.GetBool("against-template")
+		switch {
+		case withTemplate && len(args) != 1:
+			fmt.Println("failed to get required parameters err=give one flow ID with --against-template")
+			os.Exit(exitError)
+		case !withTemplate && len(args) != 2:
+			fmt.Println("failed to get required parameters err=give two flow IDs, or one with --against-template")
+			os.Exit(exitError)
+		}
+		format := diffFlowPI.V.GetString("format")
+		if format != "side-by-side" && format != "json" {
+			fmt.Printf("invalid format %q, expected side-by-side or json\n", format)
+			os.Exit(exitError)
+		}
+		var d flowDiff
+		if withTemplate {
+			d, err = diffFlowAgainstTemplate(client, args[0])
+		} else {
+			d, err = diffFlows(client, args[0], args[1])
+		}
+		if err != nil {
+			fmt.Println("error comparing flows err=" + err.Error())
+			os.Exit(exitError)
+		}
+		cleanup(diffFlowPI)
+		if format == "json" {
+			b, err := json.Marshal(d)
+			if err != nil {
+				fmt.Println("error printing diff err=" + err.Error())
+				os.Exit(exitError)
+			}
+			prettyprint(b)
+		} else {
+			d.print()
+		}
+		if len(d.Differences) > 0 {
+			os.Exit(exitChanges)
+		}
+	},
+}
+
+// flowDiff is the comparison of flow A with flow or flow template B, old values are A's and new values B's
+type flowDiff struct {
+	A           string           `json:"a"`
+	B           string           `json:"b"`
+	Differences []flowDifference `json:"differences"`
+}
+
+// flowDifference is a differing field in one section of a flowDiff
+type flowDifference struct {
+	Section string `json:"section"`
+	fieldDiff
+}
+
+func (d *flowDiff) add(section string, diffs []fieldDiff) {
+	for _, f := range diffs {
+		d.Differences = append(d.Differences, flowDifference{section, f})
+	}
+}
+
+// print renders the differences side by side, a value missing on one side as -
+func (d flowDiff) print() {
+	if len(d.Differences) == 0 {
+		fmt.Printf("No differences between %s and %s.\n", d.A, d.B)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\t%s\t%s\n", d.A, d.B)
+	section := ""
+	for _, f := range d.Differences {
+		if f.Section != section {
+			section = f.Section
+			fmt.Fprintf(w, "%s\t\t\n", section)
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", f.Path, orDefault(f.Old, "-"), orDefault(f.New, "-"))
+	}
+	w.Flush()
+}
+
+// diffFlows compares two flows, config files included
+func diffFlows(client *predixinsights.Client, idA, idB string) (flowDiff, error) {
+	a, err := findFlow(client, idA)
+	if err != nil {
+		return flowDiff{}, err
+	}
+	b, err := findFlow(client, idB)
+	if err != nil {
+		return flowDiff{}, err
+	}
+	d := flowDiff{A: fmt.Sprintf("flow %s (%s)", a.Name, a.ID), B: fmt.Sprintf("flow %s (%s)", b.Name, b.ID)}
+	d.add("flow", fieldsDiff([][3]string{
+		{"type", a.Type, b.Type},
+		{"flowTemplate.name", a.FlowTemplate.Name, b.FlowTemplate.Name},
+		{"flowTemplate.version", a.FlowTemplate.Version, b.FlowTemplate.Version},
+		{"flowTemplate.type", a.FlowTemplate.Type, b.FlowTemplate.Type},
+	}))
+	d.add("spark arguments", sparkArgsDiff(a.SparkArgs, b.SparkArgs))
+	d.add("tags", tagsDiff(flowTags(a.Tags), flowTags(b.Tags)))
+	configDiffs, err := configFilesDiff(client, a.ID, b.ID)
+	if err != nil {
+		return d, err
+	}
+	d.add("config files", configDiffs)
+	d.add("latest instance", fieldsDiff([][3]string{
+		{"status", a.LatestInstanceDetails.Summary.Status, b.LatestInstanceDetails.Summary.Status},
+	}))
+	return d, nil
+}
+
+// diffFlowAgainstTemplate compares a flow with the flow template it was created from
+func diffFlowAgainstTemplate(client *predixinsights.Client, id string) (flowDiff, error) {
+	flow, err := findFlow(client, id)
+	if err != nil {
+		return flowDiff{}, err
+	}
+	if flow.FlowTemplate.ID == "" {
+		return flowDiff{}, fmt.Errorf("flow %s is a direct flow without a flow template", flow.ID)
+	}
+	template, err := client.GetFlowTemplate(flow.FlowTemplate.ID)
+	if err != nil {
+		return flowDiff{}, err
+	}
+	d := flowDiff{A: fmt.Sprintf("flow %s (%s)", flow.Name, flow.ID), B: fmt.Sprintf("flow template %s (%s)", template.Name, template.ID)}
+	// the flow records the template version it was created from
+	d.add("flow", fieldsDiff([][3]string{
+		{"type", flow.Type, template.Type},
+		{"version", flow.FlowTemplate.Version, template.Version},
+	}))
+	d.add("spark arguments", sparkArgsDiff(flow.SparkArgs, template.SparkArgs))
+	d.add("tags", tagsDiff(flowTags(flow.Tags), template.Tags))
+	return d, nil
+}
+
+// fieldsDiff compares named string fields, each given as name, old and new value
+func fieldsDiff(fields [][3]string) []fieldDiff {
+	diffs := []fieldDiff{}
+	for _, f := range fields {
+		if f[1] != f[2] {
+			diffs = append(diffs, changed(f[0], quote(f[1]), quote(f[2])))
+		}
+	}
+	return diffs
+}
+
+// configFilesDiff compares the config files of two flows by name, JSON files by value and others by size
+func configFilesDiff(client *predixinsights.Client, idA, idB string) ([]fieldDiff, error) {
+	sizesA, err := configFileSizes(client, idA)
+	if err != nil {
+		return nil, err
+	}
+	sizesB, err := configFileSizes(client, idB)
+	if err != nil {
+		return nil, err
+	}
+	diffs := []fieldDiff{}
+	for _, name := range sortedKeys(union(sizesA, sizesB)) {
+		sizeA, inA := sizesA[name]
+		sizeB, inB := sizesB[name]
+		switch {
+		case !inA:
+			diffs = append(diffs, added(name, sizeB+" bytes"))
+		case !inB:
+			diffs = append(diffs, removed(name, sizeA+" bytes"))
+		case isJSONFileName(name):
+			contentA, err := downloadConfigFile(client, idA, name)
+			if err != nil {
+				return nil, fmt.Errorf("%s err=%s", name, err.Error())
+			}
+			contentB, err := downloadConfigFile(client, idB, name)
+			if err != nil {
+				return nil, fmt.Errorf("%s err=%s", name, err.Error())
+			}
+			for _, f := range jsonDiff(contentA, contentB) {
+				f.Path = name + f.Path
+				diffs = append(diffs, f)
+			}
+		case sizeA != sizeB:
+			diffs = append(diffs, changed(name, sizeA+" bytes", sizeB+" bytes"))
+		}
+	}
+	return diffs, nil
+}
+
+// configFileSizes returns the sizes of the config files of a flow by name
+func configFileSizes(client *predixinsights.Client, flowID string) (map[string]string, error) {
+	list, err := client.ListConfigFilesByFlowID(flowID)
+	if err != nil {
+		return nil, err
+	}
+	sizes := map[string]string{}
+	for _, f := range list {
+		if !f.Directory {
+			sizes[f.FileName] = strconv.Itoa(f.FileSize)
+		}
+	}
+	return sizes, nil
+}
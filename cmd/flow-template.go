@@ -7,6 +7,7 @@ import (
 	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // flowTemplateCmd represents the flowTemplate command
@@ -22,10 +23,11 @@ func init() {
 
 // flowTemplateCmd represents the flowTemplate command
 var postFlowTemplateCmd = &cobra.Command{
-	Use:     "create",
-	Short:   "Create a flow template",
-	Long:    `Upload a Flow Template to Predix Insights.`,
-	Example: `  pi flow-template create --desc "PI CLI Example" --flowTemplateName "pi-cli" --flowType "SPARK_JAVA" --templateFileName "spark-examples.zip" --templateFilePath "/Users/andromeda/Desktop/spark-examples.zip" --flowTemplateVersion 1.0.0`,
+	Use:   "create",
+	Short: "Create a flow template",
+	Long:  `Upload a Flow Template to Predix Insights, tagged with the tags given with --tags.`,
+	Example: `  pi flow-template create --desc "PI CLI Example" --flowTemplateName "pi-cli" --flowType "SPARK_JAVA" --templateFileName "spark-examples.zip" --templateFilePath "/Users/andromeda/Desktop/spark-examples.zip" --flowTemplateVersion 1.0.0
+  pi flow-template create --desc "PI CLI Example" --flowTemplateName "pi-cli" --flowType "SPARK_JAVA" --templateFileName "spark-examples.zip" --templateFilePath "/Users/andromeda/Desktop/spark-examples.zip" --flowTemplateVersion 1.0.0 --tags "[\"team:data\"]"`,
 	Run: func(cmd *cobra.Command, args []string) {
 		client, err := login()
 		if err != nil {
@@ -37,11 +39,30 @@ var postFlowTemplateCmd = &cobra.Command{
 			fmt.Println("failed to get required parameters err=" + err.Error())
 			return
 		}
+		tags, err := tagsFlag(cmd, postFlowTemplatePI)
+		if err != nil {
+			fmt.Println("error invalid format for tags err=" + err.Error())
+			return
+		}
+		// a flow template is created without spark arguments, only its tags can violate the policy
+		if err := enforce("flow template "+postFlowTemplatePI.V.GetString("flowTemplateName"), postFlowTemplatePI.V.GetString("flowType"), nil, tags); err != nil {
+			fmt.Println("error " + err.Error())
+			return
+		}
 		ft, err := client.PostFlowTemplate(postFlowTemplatePI.V.GetString("flowTemplateName"), postFlowTemplatePI.V.GetString("templateFileName"), postFlowTemplatePI.V.GetString("templateFilePath"), postFlowTemplatePI.V.GetString("flowTemplateVersion"), postFlowTemplatePI.V.GetString("desc"), postFlowTemplatePI.V.GetString("flowType"))
 		if err != nil {
 			fmt.Println("error posting flow tempalte err=" + err.Error())
 			return
 		}
+		if len(tags) > 0 {
+			_, err := client.SaveTagsForFlowTemplate(ft.ID, predixinsights.TagsArray(tags))
+			if err != nil {
+				fmt.Println("error saving tags for flow template " + ft.ID + " err=" + err.Error())
+				discardFlowTemplate(client, postFlowTemplatePI, ft.ID)
+				return
+			}
+			ft.Tags = tags
+		}
 		postFlowTemplatePI.V.Set("flowTemplateID", ft.ID)
 		ftByte, _ := json.Marshal(&ft)
 		prettyprint(ftByte)
@@ -49,6 +70,18 @@ var postFlowTemplateCmd = &cobra.Command{
 	},
 }
 
+// discardFlowTemplate deletes a flow template whose creation failed part way, a flow template that cannot be deleted is
+// saved to the config so it can be fixed or deleted by hand
+func discardFlowTemplate(client *predixinsights.Client, p pi, id string) {
+	if err := client.DeleteFlowTemplate(id); err != nil {
+		fmt.Println("error deleting flow template " + id + " err=" + err.Error())
+		p.V.Set("flowTemplateID", id)
+	} else {
+		fmt.Println("Deleted flow template " + id)
+	}
+	cleanup(p)
+}
+
 var updateFlowTemplateCmd = &cobra.Command{
 	Use:     "update",
 	Short:   "Update a flow template",
@@ -65,6 +98,16 @@ var updateFlowTemplateCmd = &cobra.Command{
 			fmt.Println("failed to get required parameters err=" + err.Error())
 			return
 		}
+		// the spark arguments and tags are kept, a new flow type changes how the arguments validate
+		template, err := client.GetFlowTemplate(updateFlowTemplatePI.V.GetString("flowTemplateID"))
+		if err != nil {
+			fmt.Println("error getting flow template err=" + err.Error())
+			return
+		}
+		if err := enforce("flow template "+updateFlowTemplatePI.V.GetString("flowTemplateName"), updateFlowTemplatePI.V.GetString("flowType"), &template.SparkArgs, userTags(template.Tags)); err != nil {
+			fmt.Println("error " + err.Error())
+			return
+		}
 		err = client.UpdateFlowTemplateByFlowTemplateIDUsingNewZip(updateFlowTemplatePI.V.GetString("flowTemplateID"), updateFlowTemplatePI.V.GetString("flowTemplateName"), updateFlowTemplatePI.V.GetString("templateFileName"), updateFlowTemplatePI.V.GetString("templateFilePath"), updateFlowTemplatePI.V.GetString("flowTemplateVersion"), updateFlowTemplatePI.V.GetString("desc"), updateFlowTemplatePI.V.GetString("flowType"))
 		if err != nil {
 			fmt.Println("error posting flow tempalte err=" + err.Error())
@@ -76,10 +119,14 @@ var updateFlowTemplateCmd = &cobra.Command{
 }
 
 var updateFlowTemplateChangeSparkArguments = &cobra.Command{
-	Use:     "update-spark-args",
-	Short:   "Update Flow Template Spark Arguments",
-	Long:    `Update a Predix Insights Flow Template's Spark Arguments.`,
-	Example: `  pi flow-template update-spark-args --flowTemplateID MY_FLOW_TEMPLATE_ID --sparkArgs "{\"sparkArguments\": {\"applicationArgs\":[\"100\"],\"className\":\"org.apache.spark.examples.SparkPi\"}}"`,
+	Use:   "update-spark-args",
+	Short: "Update Flow Template Spark Arguments",
+	Long: `Update a Predix Insights Flow Template's Spark Arguments, given as --sparkArgs JSON or with flags like --className and repeatable --conf KEY=VALUE.
+Presets given with --preset, see pi preset, and the flags patch the current arguments like pi flow update-spark-args, --replace
+starts from empty arguments instead. A --sparkArgs given alone replaces the arguments unless --merge is given.`,
+	Example: `  pi flow-template update-spark-args --flowTemplateID MY_FLOW_TEMPLATE_ID --sparkArgs "{\"sparkArguments\": {\"applicationArgs\":[\"100\"],\"className\":\"org.apache.spark.examples.SparkPi\"}}"
+  pi flow-template update-spark-args --flowTemplateID MY_FLOW_TEMPLATE_ID --driverMemory 2g --system-prop env=prod
+  pi flow-template update-spark-args --flowTemplateID MY_FLOW_TEMPLATE_ID --preset large-shuffle`,
 	Run: func(cmd *cobra.Command, args []string) {
 		client, err := login()
 		if err != nil {
@@ -92,14 +139,23 @@ var updateFlowTemplateChangeSparkArguments = &cobra.Command{
 			return
 		}
 
-		sparkArgs := &predixinsights.EncapsulatedSparkArgs{}
-		err = json.Unmarshal([]byte(updateFlowTemplateChangeSparkArgumentsPI.V.GetString("sparkArgs")), sparkArgs)
+		templateID := updateFlowTemplateChangeSparkArgumentsPI.V.GetString("flowTemplateID")
+		template, err := client.GetFlowTemplate(templateID)
+		if err != nil {
+			fmt.Println("error getting flow template err=" + err.Error())
+			return
+		}
+		sparkArgs, err := resolveSparkArgs(cmd, updateFlowTemplateChangeSparkArgumentsPI, &templateSparkArgFlags, template.SparkArgs)
 		if err != nil {
-			fmt.Println("error invalid format for sparkArgs err=" + err.Error())
+			fmt.Println("error resolving spark arguments err=" + err.Error())
+			return
+		}
+		if err := enforce("flow template "+template.Name, template.Type, &sparkArgs.SparkArgs, nil); err != nil {
+			fmt.Println("error " + err.Error())
 			return
 		}
 
-		err = client.UpdateFlowTemplateByFlowTemplateIDChangeSparkArguments(updateFlowTemplateChangeSparkArgumentsPI.V.GetString("flowTemplateID"), *sparkArgs)
+		err = client.UpdateFlowTemplateByFlowTemplateIDChangeSparkArguments(templateID, sparkArgs)
 		if err != nil {
 			fmt.Println("error updating flow template spark arguments err=" + err.Error())
 			return
@@ -201,11 +257,15 @@ var saveFlowTemplateTagsCmd = &cobra.Command{
 			return
 		}
 		tagsArray := &predixinsights.TagsArray{}
-		err = json.Unmarshal([]byte(saveFlowTemplateTagsPI.V.GetString("tags")), tagsArray)
+		err = decodeJSONFlag(saveFlowTemplateTagsPI, "tags", tagsArray)
 		if err != nil {
 			fmt.Println("error invalid format for tags err=" + err.Error())
 			return
 		}
+		if err := enforce("flow template "+saveFlowTemplateTagsPI.V.GetString("flowTemplateID"), "", nil, []string(*tagsArray)); err != nil {
+			fmt.Println("error " + err.Error())
+			return
+		}
 		saveTagsForFlowTemplateResponse, err := client.SaveTagsForFlowTemplate(saveFlowTemplateTagsPI.V.GetString("flowTemplateID"), *tagsArray)
 		if err != nil {
 			fmt.Println("error saving flow template tags err=" + err.Error())
@@ -234,7 +294,7 @@ var deleteFlowTemplateCmd = &cobra.Command{
 			fmt.Println("failed to get required parameters err=" + err.Error())
 			return
 		}
-		if !deleteFlowTemplatePI.V.GetBool("force") {
+		if !deleteFlowTemplatePI.V.GetBool("force") && !viper.GetBool("dry-run") {
 			fmt.Printf("Really delete the Flow Template '%s'? ", deleteFlowTemplatePI.V.GetString("flowTemplateID"))
 			if !askForConfirmation() {
 				return
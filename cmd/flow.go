@@ -3,9 +3,17 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // flowCmd represents the flow command
@@ -90,7 +98,7 @@ var deleteFlowCmd = &cobra.Command{
 			fmt.Println("failed to get required parameters err=" + err.Error())
 			return
 		}
-		if !deleteFlowPI.V.GetBool("force") {
+		if !deleteFlowPI.V.GetBool("force") && !viper.GetBool("dry-run") {
 			fmt.Printf("Really delete the flow '%s'? ", deleteFlowPI.V.GetString("flowID"))
 			if !askForConfirmation() {
 				return
@@ -112,8 +120,8 @@ var deleteFlowCmd = &cobra.Command{
 var postFlowCmd = &cobra.Command{
 	Use:     "create",
 	Short:   "Create a Flow",
-	Long:    `Create a Predix Insights Flow.`,
-	Example: "  pi flow delete --flowName MY_FLOW_NAME --flowTemplateID MY_FLOW_TEMPLATE_ID",
+	Long:    `Create a Predix Insights Flow with the tags given with --tags. A flow created with --ttl is also tagged pi-ttl:<expiry> and deleted by pi gc once it expired. The presets given with --preset, see pi preset, are layered on the spark arguments of the flow template.`,
+	Example: "  pi flow create --flowName MY_FLOW_NAME --flowTemplateID MY_FLOW_TEMPLATE_ID\n  pi flow create --flowName MY_FLOW_NAME --flowTemplateID MY_FLOW_TEMPLATE_ID --tags \"[\\\"team:data\\\"]\" --ttl 72h\n  pi flow create --flowName MY_FLOW_NAME --flowTemplateID MY_FLOW_TEMPLATE_ID --preset large-shuffle --preset gc-tuned",
 	Run: func(cmd *cobra.Command, args []string) {
 		client, err := login()
 		if err != nil {
@@ -125,11 +133,60 @@ var postFlowCmd = &cobra.Command{
 			fmt.Println("failed to get required parameters err=" + err.Error())
 			return
 		}
+		tags, err := tagsFlag(cmd, postFlowPI)
+		if err != nil {
+			fmt.Println("error invalid format for tags err=" + err.Error())
+			return
+		}
+		ttl, err := ttlTags(postFlowPI.V.GetString("ttl"))
+		if err != nil {
+			fmt.Println("invalid ttl err=" + err.Error())
+			return
+		}
+		tags = append(tags, ttl...)
+		// the flow starts with the spark arguments of its template
+		template, err := client.GetFlowTemplate(postFlowPI.V.GetString("flowTemplateID"))
+		if err != nil {
+			fmt.Println("error getting flow template err=" + err.Error())
+			return
+		}
+		sparkArgs, err := copySparkArgs(template.SparkArgs)
+		if err == nil {
+			err = applyPresets(&sparkArgs, createPresets)
+		}
+		if err != nil {
+			fmt.Println("error applying preset err=" + err.Error())
+			return
+		}
+		if err := enforce("flow "+postFlowPI.V.GetString("flowName"), template.Type, &sparkArgs, tags); err != nil {
+			fmt.Println("error " + err.Error())
+			return
+		}
 		flow, err := client.PostFlow(postFlowPI.V.GetString("flowName"), postFlowPI.V.GetString("flowTemplateID"))
 		if err != nil {
 			fmt.Println("error posting flow err=" + err.Error())
 			return
 		}
+		if len(createPresets) > 0 {
+			err = client.UpdateFlowChangeSparkArguments(postFlowPI.V.GetString("flowTemplateID"), flow.ID, predixinsights.EncapsulatedSparkArgs{SparkArgs: sparkArgs})
+			if err != nil {
+				fmt.Println("error applying preset to flow " + flow.ID + " err=" + err.Error())
+				discardFlow(client, postFlowPI, flow.ID, flow.Name)
+				return
+			}
+			flow.SparkArgs = sparkArgs
+		}
+		if len(tags) > 0 {
+			_, err = client.SaveTagsForFlow(postFlowPI.V.GetString("flowTemplateID"), flow.ID, predixinsights.TagsArray(tags))
+			if err != nil {
+				fmt.Println("error saving tags for flow " + flow.ID + " err=" + err.Error())
+				discardFlow(client, postFlowPI, flow.ID, flow.Name)
+				return
+			}
+			for _, t := range tags {
+				flow.Tags = append(flow.Tags, t)
+			}
+		}
 
 		postFlowPI.V.Set("flowID", flow.ID)
 		postFlowPI.V.Set("flowName", flow.Name)
@@ -139,10 +196,37 @@ var postFlowCmd = &cobra.Command{
 	},
 }
 
+// tagsFlag returns the tags given with --tags, a JSON array, or none. Only a --tags given on the command line counts,
+// the value saved to the config file by save-tags is not reused.
+func tagsFlag(cmd *cobra.Command, p pi) ([]string, error) {
+	tags := []string{}
+	if !cmd.Flags().Changed("tags") {
+		return tags, nil
+	}
+	tagsArray := predixinsights.TagsArray{}
+	if err := decodeJSONFlag(p, "tags", &tagsArray); err != nil {
+		return nil, err
+	}
+	return append(tags, tagsArray...), nil
+}
+
+// discardFlow deletes a flow whose creation failed part way, a flow that cannot be deleted is saved to the config so
+// it can be fixed or deleted by hand
+func discardFlow(client *predixinsights.Client, p pi, id, name string) {
+	if err := client.DeleteFlowByFlowIDOnly(id); err != nil {
+		fmt.Println("error deleting flow " + id + " err=" + err.Error())
+		p.V.Set("flowID", id)
+		p.V.Set("flowName", name)
+	} else {
+		fmt.Println("Deleted flow " + id)
+	}
+	cleanup(p)
+}
+
 var postDirectFlowCmd = &cobra.Command{
 	Use:     "create-direct",
 	Short:   "Create a Direct Flow",
-	Long:    `Create a Predix Insights Direct Flow.`,
+	Long:    `Create a Predix Insights Direct Flow with the tags given with --tags. A flow created with --ttl is also tagged pi-ttl:<expiry> and deleted by pi gc once it expired.`,
 	Example: "  pi flow create-direct --flowName MY_FLOW_NAME --flowFileName test.zip --flowFilePath /Users/andromeda/Desktop/test.zip --flowVersion 1.0.0 --desc \"My description\" --flowType SPARK_JAVA",
 	Run: func(cmd *cobra.Command, args []string) {
 		client, err := login()
@@ -155,7 +239,23 @@ var postDirectFlowCmd = &cobra.Command{
 			fmt.Println("failed to get required parameters err=" + err.Error())
 			return
 		}
-		flow, err := client.PostFlowDirectly(postDirectFlowPI.V.GetString("flowName"), postDirectFlowPI.V.GetString("flowFileName"), postDirectFlowPI.V.GetString("flowFilePath"), postDirectFlowPI.V.GetString("flowVersion"), postDirectFlowPI.V.GetString("desc"), postDirectFlowPI.V.GetString("flowType"))
+		tags, err := tagsFlag(cmd, postDirectFlowPI)
+		if err != nil {
+			fmt.Println("error invalid format for tags err=" + err.Error())
+			return
+		}
+		ttl, err := ttlTags(postDirectFlowPI.V.GetString("ttl"))
+		if err != nil {
+			fmt.Println("invalid ttl err=" + err.Error())
+			return
+		}
+		tags = append(tags, ttl...)
+		// a direct flow is created without spark arguments, only its tags can violate the policy
+		if err := enforce("flow "+postDirectFlowPI.V.GetString("flowName"), postDirectFlowPI.V.GetString("flowType"), nil, tags); err != nil {
+			fmt.Println("error " + err.Error())
+			return
+		}
+		flow, err := postFlowDirectly(client, postDirectFlowPI.V.GetString("flowName"), postDirectFlowPI.V.GetString("flowFileName"), postDirectFlowPI.V.GetString("flowFilePath"), postDirectFlowPI.V.GetString("flowVersion"), postDirectFlowPI.V.GetString("desc"), postDirectFlowPI.V.GetString("flowType"), tags, nil)
 		if err != nil {
 			fmt.Println("error posting direct flow err=" + err.Error())
 			return
@@ -169,6 +269,155 @@ var postDirectFlowCmd = &cobra.Command{
 	},
 }
 
+var cloneFlowCmd = &cobra.Command{
+	Use:   "clone",
+	Short: "Clone a Flow",
+	Long: `Create a copy of a Predix Insights Flow with its spark arguments, config files and tags.
+The copy uses the flow template of the source unless --flowTemplateID is given. The API does not return the artifact of a direct flow,
+so a direct flow is cloned under --flowTemplateID, or as a direct flow from --flowFilePath.
+A copy that fails part way is deleted again.`,
+	Example: "  pi flow clone --flowID MY_FLOW_ID --name MY_NEW_FLOW_NAME\n  pi flow clone --flowID MY_FLOW_ID --name MY_NEW_FLOW_NAME --flowTemplateID MY_FLOW_TEMPLATE_ID\n  pi flow clone --flowID MY_DIRECT_FLOW_ID --name MY_NEW_FLOW_NAME --flowFilePath /Users/andromeda/Desktop/test.zip",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := login()
+		if err != nil {
+			fmt.Println("authentication error err=" + err.Error())
+			return
+		}
+		err = getMissingRequiredParams(cloneFlowPI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			return
+		}
+		// flowTemplateID and flowFilePath fall back to the config file, only explicit values apply to the clone
+		templateID, filePath := "", ""
+		if cmd.Flags().Changed("flowTemplateID") {
+			templateID = cloneFlowPI.V.GetString("flowTemplateID")
+		}
+		if cmd.Flags().Changed("flowFilePath") {
+			filePath = cloneFlowPI.V.GetString("flowFilePath")
+		}
+		flow, err := cloneFlow(client, cloneFlowPI.V.GetString("flowID"), cloneFlowPI.V.GetString("name"), templateID, filePath)
+		if err != nil {
+			fmt.Println("error cloning flow err=" + err.Error())
+			return
+		}
+
+		cloneFlowPI.V.Set("flowID", flow.ID)
+		cloneFlowPI.V.Set("flowName", flow.Name)
+		flowByte, _ := json.Marshal(&flow)
+		prettyprint(flowByte)
+		cleanup(cloneFlowPI)
+	},
+}
+
+// cloneFlow creates name as a copy of the flow sourceID, under templateID or as a direct flow from filePath when given
+func cloneFlow(client *predixinsights.Client, sourceID, name, templateID, filePath string) (predixinsights.Flow, error) {
+	source, err := findFlow(client, sourceID)
+	if err != nil {
+		return predixinsights.Flow{}, err
+	}
+	if templateID == "" && filePath == "" {
+		templateID = source.FlowTemplate.ID
+		if templateID == "" {
+			return predixinsights.Flow{}, fmt.Errorf("flow '%s' is a direct flow, give --flowTemplateID or the artifact with --flowFilePath", source.Name)
+		}
+	}
+	if err := enforce("flow "+name, source.Type, &source.SparkArgs, userTags(flowTags(source.Tags))); err != nil {
+		return predixinsights.Flow{}, err
+	}
+
+	// config files are downloaded first, a flow whose config files cannot be copied is not cloned
+	dir, err := ioutil.TempDir("", "pi-clone")
+	if err != nil {
+		return predixinsights.Flow{}, err
+	}
+	defer os.RemoveAll(dir)
+	configFiles, notDownloaded, err := exportConfigFiles(client, source, dir)
+	if err == nil && len(notDownloaded) > 0 {
+		err = fmt.Errorf("only JSON config files can be downloaded, not %s", strings.Join(notDownloaded, ", "))
+	}
+	if err != nil {
+		return predixinsights.Flow{}, fmt.Errorf("downloading config files failed err=%s", err.Error())
+	}
+
+	// tags reserved for the CLI describe the source, not the copy
+	tags := userTags(flowTags(source.Tags))
+	var id string
+	if templateID != "" {
+		flow, err := client.PostFlow(name, templateID)
+		if err != nil {
+			return predixinsights.Flow{}, err
+		}
+		id = flow.ID
+	} else {
+		// a direct flow has no flow template to save spark arguments and tags through, they are uploaded with it
+		description, _ := source.Description.(string)
+		flow, err := postFlowDirectly(client, name, filepath.Base(filePath), filePath, source.Version, description, source.Type, tags, &source.SparkArgs)
+		if err != nil {
+			return predixinsights.Flow{}, err
+		}
+		id = flow.ID
+	}
+	// a partial copy is deleted, so the clone can simply be retried
+	rollback := func(step string, err error) (predixinsights.Flow, error) {
+		if deleteErr := client.DeleteFlowByFlowIDOnly(id); deleteErr != nil {
+			return predixinsights.Flow{}, fmt.Errorf("flow %s created, %s failed err=%s, deleting flow %s failed err=%s", id, step, err.Error(), id, deleteErr.Error())
+		}
+		return predixinsights.Flow{}, fmt.Errorf("%s failed, flow %s was deleted err=%s", step, id, err.Error())
+	}
+	var clone predixinsights.Flow
+	if dryRunCreated(id) {
+		clone.ID, clone.Name, clone.Type = id, name, source.Type
+		clone.FlowTemplate.ID = templateID
+	} else if clone, err = findFlow(client, id); err != nil {
+		return rollback("reading the new flow", err)
+	}
+
+	if clone.FlowTemplate.ID != "" {
+		err = client.UpdateFlowChangeSparkArguments(clone.FlowTemplate.ID, id, predixinsights.EncapsulatedSparkArgs{SparkArgs: source.SparkArgs})
+		if err != nil {
+			return rollback("copying spark arguments", err)
+		}
+		clone.SparkArgs = source.SparkArgs
+		if len(tags) > 0 {
+			_, err = client.SaveTagsForFlow(clone.FlowTemplate.ID, id, predixinsights.TagsArray(tags))
+			if err != nil {
+				return rollback("copying tags", err)
+			}
+			clone.Tags = []interface{}{}
+			for _, t := range tags {
+				clone.Tags = append(clone.Tags, t)
+			}
+		}
+	}
+
+	if len(configFiles) > 0 {
+		fileDetails := []predixinsights.FileDetails{}
+		for _, cf := range configFiles {
+			fileDetails = append(fileDetails, predixinsights.FileDetails{FileName: cf.Name, FileLocation: filepath.Join(dir, cf.File)})
+		}
+		err = client.UpdateFlowByFlowIDAddConfigFile(id, fileDetails)
+		if err != nil {
+			return rollback("copying config files", err)
+		}
+	}
+	return clone, nil
+}
+
+// findFlow looks a flow up by ID, which also works for direct flows
+func findFlow(client *predixinsights.Client, id string) (predixinsights.Flow, error) {
+	flows, err := client.GetAllFlows(maxFlowPages)
+	if err != nil {
+		return predixinsights.Flow{}, err
+	}
+	for _, f := range flows {
+		if f.ID == id {
+			return f, nil
+		}
+	}
+	return predixinsights.Flow{}, fmt.Errorf("flow '%s' not found", id)
+}
+
 var updateDirectFlowCmd = &cobra.Command{
 	Use:     "update-direct",
 	Short:   "Update a Direct Flow",
@@ -200,33 +449,165 @@ var updateDirectFlowCmd = &cobra.Command{
 }
 
 var postLaunchFlowCmd = &cobra.Command{
-	Use:     "launch",
-	Short:   "Launch a Flow",
-	Long:    `Launch a Predix Insights Flow.`,
-	Example: "  pi flow launch --flowID MY_FLOW_ID --flowTemplateID MY_FLOW_TEMPLATE_ID",
+	Use:   "launch",
+	Short: "Launch a Flow",
+	Long: `Launch a Predix Insights Flow. With --wait the instance is polled like pi instance wait, with the same exit codes.
+--set KEY=VALUE overrides a spark argument for this launch only, the flow's arguments are restored once YARN accepted the instance.
+Map arguments are set by entry, e.g. confs.spark.sql.shuffle.partitions=400, list arguments take a JSON array or comma separated values.
+Config files with a JSON Schema are validated first, see pi flow add-config-file, and the flow is not launched when one does not match.`,
+	Example: "  pi flow launch --flowID MY_FLOW_ID --flowTemplateID MY_FLOW_TEMPLATE_ID\n  pi flow launch --flowID MY_FLOW_ID --flowTemplateID MY_FLOW_TEMPLATE_ID --wait --timeout 1h --stderr-lines 100\n  pi flow launch --flowID MY_FLOW_ID --flowTemplateID MY_FLOW_TEMPLATE_ID --set applicationArgs=2020-01-01,2020-01-31 --set confs.spark.sql.shuffle.partitions=400",
 	Run: func(cmd *cobra.Command, args []string) {
+		// with --wait the exit code reports the outcome, as for pi instance wait
+		launchFailed := func() {
+			if postLaunchFlowPI.V.GetBool("wait") {
+				os.Exit(exitError)
+			}
+		}
 		client, err := login()
 		if err != nil {
 			fmt.Println("authentication error err=" + err.Error())
+			launchFailed()
 			return
 		}
 		err = getMissingRequiredParams(postLaunchFlowPI)
 		if err != nil {
 			fmt.Println("failed to get required parameters err=" + err.Error())
+			launchFailed()
+			return
+		}
+		flow, err := client.GetFlowByTemplateIDAndFlowID(postLaunchFlowPI.V.GetString("flowTemplateID"), postLaunchFlowPI.V.GetString("flowID"))
+		if err != nil {
+			fmt.Println("error getting flow err=" + err.Error())
+			launchFailed()
 			return
 		}
-		launchResponse, err := client.LaunchFlow(postLaunchFlowPI.V.GetString("flowTemplateID"), postLaunchFlowPI.V.GetString("flowID"))
+		launchArgs, err := setSparkArgs(flow.SparkArgs, launchSets)
 		if err != nil {
 			fmt.Println("error launching flow err=" + err.Error())
+			launchFailed()
+			return
+		}
+		if err := enforce("flow "+flow.Name, flow.Type, &launchArgs, flowTags(flow.Tags)); err != nil {
+			fmt.Println("error " + err.Error())
+			launchFailed()
+			return
+		}
+		schemas, err := flowConfigSchemas(client, flowTags(flow.Tags), postLaunchFlowPI.V.GetString("flowTemplateID"), postLaunchFlowPI.V.GetString("templateFilePath"))
+		if err != nil {
+			fmt.Println("error loading config file schemas err=" + err.Error())
+			launchFailed()
+			return
+		}
+		if err := validateFlowConfigFiles(client, postLaunchFlowPI.V.GetString("flowID"), schemas); err != nil {
+			fmt.Println("error validating config file(s) err=" + err.Error())
+			launchFailed()
+			return
+		}
+		var launchResponse predixinsights.LaunchResponse
+		if len(launchSets) > 0 {
+			launchResponse, err = launchWithOverrides(client, postLaunchFlowPI.V.GetString("flowTemplateID"), postLaunchFlowPI.V.GetString("flowID"), launchSets)
+		} else {
+			launchResponse, err = client.LaunchFlow(postLaunchFlowPI.V.GetString("flowTemplateID"), postLaunchFlowPI.V.GetString("flowID"))
+		}
+		if err != nil {
+			fmt.Println("error launching flow err=" + err.Error())
+			launchFailed()
 			return
 		}
 		postLaunchFlowPI.V.Set("instanceID", launchResponse.ID)
 		postFlowByte, _ := json.Marshal(&launchResponse)
 		prettyprint(postFlowByte)
 		cleanup(postLaunchFlowPI)
+		if postLaunchFlowPI.V.GetBool("wait") && dryRunCreated(launchResponse.ID) {
+			fmt.Println("dry run, not waiting for an instance that was not launched")
+		} else if postLaunchFlowPI.V.GetBool("wait") {
+			if code := waitAndReport(client, postLaunchFlowPI, launchResponse.ID); code != 0 {
+				os.Exit(code)
+			}
+		}
 	},
 }
 
+const (
+	// launchAcceptTimeout bounds how long launch --set keeps the overrides waiting for YARN to accept the instance
+	launchAcceptTimeout = 5 * time.Minute
+	// launchAcceptPoll is the time between polls of the launched instance
+	launchAcceptPoll = 2 * time.Second
+)
+
+// launchWithOverrides launches a flow once with sets applied to its spark arguments, restoring the original
+// arguments afterwards. A local lock keeps concurrent launches of the flow from restoring each other's overrides.
+func launchWithOverrides(client *predixinsights.Client, templateID, flowID string, sets []string) (predixinsights.LaunchResponse, error) {
+	lock, err := acquireLock("launch-"+flowID, "pi flow launch --set")
+	if err != nil {
+		return predixinsights.LaunchResponse{}, err
+	}
+	flow, err := client.GetFlowByTemplateIDAndFlowID(templateID, flowID)
+	if err != nil {
+		lock.release()
+		return predixinsights.LaunchResponse{}, err
+	}
+	original := flow.SparkArgs
+	overridden, err := setSparkArgs(original, sets)
+	if err != nil {
+		lock.release()
+		return predixinsights.LaunchResponse{}, err
+	}
+	// the lock file keeps the original arguments until they are restored
+	if err := lock.record(predixinsights.EncapsulatedSparkArgs{SparkArgs: original}); err != nil {
+		lock.release()
+		return predixinsights.LaunchResponse{}, err
+	}
+
+	// an interrupt between the update and the restore would leave the overrides in place
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var launchResponse predixinsights.LaunchResponse
+	err = client.UpdateFlowChangeSparkArguments(templateID, flowID, predixinsights.EncapsulatedSparkArgs{SparkArgs: overridden})
+	if err == nil {
+		launchResponse, err = client.LaunchFlow(templateID, flowID)
+	}
+	// the spark arguments are read when YARN accepts the instance, not when the launch returns
+	if err == nil && !dryRunCreated(launchResponse.ID) {
+		if acceptErr := awaitAccepted(client, launchResponse.ID); acceptErr != nil {
+			fmt.Fprintf(os.Stderr, "warning: restoring the spark arguments of flow %s before instance %s was accepted err=%s\n", flowID, launchResponse.ID, acceptErr.Error())
+		}
+	}
+	if restoreErr := client.UpdateFlowChangeSparkArguments(templateID, flowID, predixinsights.EncapsulatedSparkArgs{SparkArgs: original}); restoreErr != nil {
+		return launchResponse, fmt.Errorf("restoring the spark arguments failed, they are saved in %s err=%s", lock.path, restoreErr.Error())
+	}
+	lock.release()
+	select {
+	case sig := <-signals:
+		return launchResponse, fmt.Errorf("interrupted by %s, spark arguments restored", sig)
+	default:
+	}
+	return launchResponse, err
+}
+
+// awaitAccepted polls an instance until YARN accepted it, a final state counts as accepted
+func awaitAccepted(client *predixinsights.Client, instanceID string) error {
+	deadline := time.Now().Add(launchAcceptTimeout)
+	for {
+		instance, err := client.GetInstance(instanceID)
+		if err == nil {
+			state := instance.Details.YarnApplicationState
+			if state == "ACCEPTED" || state == "RUNNING" || finalState(state) {
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("instance still %s after %s", instance.Details.YarnApplicationState, launchAcceptTimeout)
+		}
+		time.Sleep(launchAcceptPoll)
+	}
+}
+
 var stopFlowCmd = &cobra.Command{
 	Use:     "stop",
 	Short:   "Stop a Flow",
@@ -269,6 +650,16 @@ var createFlowTemplateFromFlowCmd = &cobra.Command{
 			fmt.Println("failed to get required parameters err=" + err.Error())
 			return
 		}
+		// the flow template takes the spark arguments and tags of the flow
+		flow, err := findFlow(client, createFlowTemplateFromFlowPI.V.GetString("flowID"))
+		if err != nil {
+			fmt.Println("error getting flow err=" + err.Error())
+			return
+		}
+		if err := enforce("flow template from flow "+flow.Name, flow.Type, &flow.SparkArgs, userTags(flowTags(flow.Tags))); err != nil {
+			fmt.Println("error " + err.Error())
+			return
+		}
 		ft, err := client.CreateFlowTemplateFromFlow(createFlowTemplateFromFlowPI.V.GetString("flowID"))
 		if err != nil {
 			fmt.Println("error stopping flow err=" + err.Error())
@@ -283,10 +674,18 @@ var createFlowTemplateFromFlowCmd = &cobra.Command{
 }
 
 var updateFlowChangeSparkArguments = &cobra.Command{
-	Use:     "update-spark-args",
-	Short:   "Update Flow Spark Arguments",
-	Long:    `Update a Predix Insights Flow's Spark Arguments.`,
-	Example: `  pi flow update-spark-args --sparkArgs "{\"sparkArguments\": {\"applicationArgs\":[\"100\"],\"className\":\"org.apache.spark.examples.SparkPi\"}}" -i\n pi flow update-spark-args --sparkArgs "{\"sparkArguments\": {\"applicationArgs\":[\"1000\"],\"className\":\"org.apache.spark.examples.SparkPi\"}}" --flowTemplateID MY_FLOW_TEMPLATE_ID --flowID MY_FLOW_ID`,
+	Use:   "update-spark-args",
+	Short: "Update Flow Spark Arguments",
+	Long: `Update a Predix Insights Flow's Spark Arguments, given as --sparkArgs JSON or with flags like --className and repeatable --conf KEY=VALUE.
+Presets given with --preset, see pi preset, and the flags patch the current arguments: fields given are set, --conf, --executor-env and
+--system-prop entries are added and --app-arg and --listener lists are replaced. Presets are layered first, then --sparkArgs as a JSON
+merge patch, then the flags. --replace starts from empty arguments instead. A --sparkArgs given alone replaces the arguments unless
+--merge is given.`,
+	Example: `  pi flow update-spark-args --sparkArgs "{\"sparkArguments\": {\"applicationArgs\":[\"100\"],\"className\":\"org.apache.spark.examples.SparkPi\"}}" -i\n pi flow update-spark-args --sparkArgs "{\"sparkArguments\": {\"applicationArgs\":[\"1000\"],\"className\":\"org.apache.spark.examples.SparkPi\"}}" --flowTemplateID MY_FLOW_TEMPLATE_ID --flowID MY_FLOW_ID
+  pi flow update-spark-args --flowID MY_FLOW_ID --className org.apache.spark.examples.SparkPi --app-arg 1000 --conf spark.sql.shuffle.partitions=400
+  pi flow update-spark-args --flowID MY_FLOW_ID --executorMemory 8g --conf spark.sql.shuffle.partitions=800
+  pi flow update-spark-args --flowID MY_FLOW_ID --preset large-shuffle --preset gc-tuned
+  pi flow update-spark-args --flowID MY_FLOW_ID --replace --preset small --className org.apache.spark.examples.SparkPi`,
 	Run: func(cmd *cobra.Command, args []string) {
 		client, err := login()
 		if err != nil {
@@ -298,14 +697,23 @@ var updateFlowChangeSparkArguments = &cobra.Command{
 			fmt.Println("failed to get required parameters err=" + err.Error())
 			return
 		}
-		sparkArgs := &predixinsights.EncapsulatedSparkArgs{}
-		err = json.Unmarshal([]byte(updateFlowChangeSparkArgumentsPI.V.GetString("sparkArgs")), sparkArgs)
+		templateID, flowID := updateFlowChangeSparkArgumentsPI.V.GetString("flowTemplateID"), updateFlowChangeSparkArgumentsPI.V.GetString("flowID")
+		flow, err := client.GetFlowByTemplateIDAndFlowID(templateID, flowID)
+		if err != nil {
+			fmt.Println("error getting flow err=" + err.Error())
+			return
+		}
+		sparkArgs, err := resolveSparkArgs(cmd, updateFlowChangeSparkArgumentsPI, &flowSparkArgFlags, flow.SparkArgs)
 		if err != nil {
-			fmt.Println("error invalid format for sparkArgs err=" + err.Error())
+			fmt.Println("error resolving spark arguments err=" + err.Error())
+			return
+		}
+		if err := enforce("flow "+flow.Name, flow.Type, &sparkArgs.SparkArgs, nil); err != nil {
+			fmt.Println("error " + err.Error())
 			return
 		}
 
-		err = client.UpdateFlowChangeSparkArguments(updateFlowChangeSparkArgumentsPI.V.GetString("flowTemplateID"), updateFlowChangeSparkArgumentsPI.V.GetString("flowID"), *sparkArgs)
+		err = client.UpdateFlowChangeSparkArguments(templateID, flowID, sparkArgs)
 		if err != nil {
 			fmt.Println("error updating flow spark arguments err=" + err.Error())
 			return
@@ -316,11 +724,18 @@ var updateFlowChangeSparkArguments = &cobra.Command{
 }
 
 var addFlowConfigFiles = &cobra.Command{
-	Use:     "add-config-file",
-	Short:   "Add Config File(s) to a Flow",
-	Long:    `Add configuration file(s) to a Predix Insights Flow.`,
-	Example: `  pi flow add-config-file --flowID MY_FLOW_ID --configFileDetails "[{\"FileName\": \"config.json\", \"FileLocation\": \"/Users/andromeda/Desktop/config.json\"}]"`,
+	Use:   "add-config-file",
+	Short: "Add Config File(s) to a Flow",
+	Long: `Add configuration file(s) to a Predix Insights Flow.
+With --render the files are Go templates rendered with --vars, the environment, the tenant ID and secrets, see pi flow config render.
+Rendered files are uploaded from memory and never written to disk. Files with a JSON Schema, declared in ~/.pi/schemas.yaml, .pi/schemas.yaml
+or by a schema:CONFIG_FILE=PATH tag of the flow or its template, are validated first and none are uploaded when one does not match.`,
+	Example: `  pi flow add-config-file --flowID MY_FLOW_ID --configFileDetails "[{\"FileName\": \"config.json\", \"FileLocation\": \"/Users/andromeda/Desktop/config.json\"}]"
+  pi flow add-config-file --flowID MY_FLOW_ID --configFileDetails "[{\"FileName\": \"config.json\", \"FileLocation\": \"conf/config.json\"}]" --render --vars conf/prod.yaml`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if addFlowConfigFilesPI.V.GetBool("render") {
+			bypassCacheForSecrets()
+		}
 		client, err := login()
 		if err != nil {
 			fmt.Println("authentication error err=" + err.Error())
@@ -332,13 +747,34 @@ var addFlowConfigFiles = &cobra.Command{
 			return
 		}
 		fileDetails := []predixinsights.FileDetails{}
-		err = json.Unmarshal([]byte(addFlowConfigFilesPI.V.GetString("configFileDetails")), &fileDetails)
+		err = decodeJSONFlag(addFlowConfigFilesPI, "configFileDetails", &fileDetails)
 		if err != nil {
 			fmt.Println("failed to parse configFileDetails err=" + err.Error())
 			return
 		}
+		var rendered map[string][]byte
+		if addFlowConfigFilesPI.V.GetBool("render") {
+			t, err := newConfigTemplate(addFlowConfigFilesPI.V.GetString("vars"), addFlowConfigFilesPI.V.GetString("secrets"), false)
+			if err != nil {
+				fmt.Println("error " + err.Error())
+				return
+			}
+			if rendered, err = renderFileDetails(t, fileDetails); err != nil {
+				fmt.Println("error rendering config file err=" + err.Error())
+				return
+			}
+		}
+		schemas, err := schemasOfFlow(client, addFlowConfigFilesPI.V.GetString("flowID"), addFlowConfigFilesPI.V.GetString("templateFilePath"))
+		if err != nil {
+			fmt.Println("error loading config file schemas err=" + err.Error())
+			return
+		}
+		if err := schemas.validateUploads(fileDetails, rendered); err != nil {
+			fmt.Println("error validating config file(s) err=" + err.Error())
+			return
+		}
 
-		err = client.UpdateFlowByFlowIDAddConfigFile(addFlowConfigFilesPI.V.GetString("flowID"), fileDetails)
+		err = addConfigFiles(client, addFlowConfigFilesPI.V.GetString("flowID"), fileDetails, rendered)
 		if err != nil {
 			fmt.Println("error adding config file(s) to flow err=" + err.Error())
 			return
@@ -418,11 +854,15 @@ var saveFlowTagsCmd = &cobra.Command{
 			return
 		}
 		tagsArray := &predixinsights.TagsArray{}
-		err = json.Unmarshal([]byte(saveFlowTagsPI.V.GetString("tags")), tagsArray)
+		err = decodeJSONFlag(saveFlowTagsPI, "tags", tagsArray)
 		if err != nil {
 			fmt.Println("error invalid format for tags err=" + err.Error())
 			return
 		}
+		if err := enforce("flow "+saveFlowTagsPI.V.GetString("flowID"), "", nil, []string(*tagsArray)); err != nil {
+			fmt.Println("error " + err.Error())
+			return
+		}
 		flowResponse, err := client.SaveTagsForFlow(saveFlowTagsPI.V.GetString("flowTemplateID"), saveFlowTagsPI.V.GetString("flowID"), *tagsArray)
 		if err != nil {
 			fmt.Println("error saving flow tags err=" + err.Error())
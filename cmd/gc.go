@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ttlTagPrefix tags flows created with --ttl with their RFC 3339 expiry
+const ttlTagPrefix = "pi-ttl:"
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Delete Expired Flows and Unused Flow Templates",
+	Long: `Find flows whose pi-ttl tag expired, see pi flow create --ttl, and the flow templates left without flows by them, and delete them after confirmation.
+Flow templates with an expired pi-ttl tag of their own and no flows are deleted too. Flows whose latest instance is still running are skipped,
+as are flow templates created within --min-age. Flows and flow templates without a pi-ttl tag or expired flows are never deleted.
+The report has one line per resource and a summary line, without prompts when --yes is given, so it can be mailed by cron. Without --yes and a terminal nothing is deleted.
+The exit code is 1 when a deletion failed.`,
+	Example: "  pi gc\n  pi gc --yes --min-age 168h",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := login()
+		if err != nil {
+			fmt.Println("authentication error err=" + err.Error())
+			os.Exit(exitError)
+		}
+		err = getMissingRequiredParams(gcPI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			os.Exit(exitError)
+		}
+		minAge, err := parseDurationFlag(gcPI, "min-age")
+		if err != nil {
+			fmt.Println("invalid min-age err=" + err.Error())
+			os.Exit(exitError)
+		}
+		now := time.Now().UTC()
+		candidates, err := findGarbage(client, now, minAge)
+		if err != nil {
+			fmt.Println("error finding expired resources err=" + err.Error())
+			os.Exit(exitError)
+		}
+		fmt.Printf("gc %s tenant=%s\n", now.Format(time.RFC3339), gcPI.V.GetString("TenantID"))
+		deletable := 0
+		for _, c := range candidates {
+			if c.skip == "" {
+				deletable++
+			}
+		}
+		if deletable > 0 && !gcPI.V.GetBool("yes") && !viper.GetBool("dry-run") {
+			for _, c := range candidates {
+				c.report("delete")
+			}
+			if !isatty.IsTerminal(os.Stdin.Fd()) {
+				fmt.Printf("gc: %d to delete, nothing deleted without --yes\n", deletable)
+				return
+			}
+			fmt.Printf("\nDelete %d resource(s)? ", deletable)
+			if !askForConfirmation() {
+				return
+			}
+		}
+		if failed := collectGarbage(client, candidates); failed > 0 {
+			os.Exit(exitError)
+		}
+	},
+}
+
+// garbage is a flow or flow template found by gc, skip holds the reason it is kept
+type garbage struct {
+	kind, name, id string
+	templateID     string
+	reason, skip   string
+}
+
+// report prints the line of one resource, skipped resources are reported with the reason they are kept
+func (g garbage) report(action string) {
+	reason := g.reason
+	if g.skip != "" {
+		action, reason = "skipped", g.skip
+	}
+	fmt.Printf("%-8s %-13s %s (%s) %s\n", action, g.kind, g.name, g.id, reason)
+}
+
+// findGarbage returns the expired flows first, then the flow templates left without flows once they are deleted
+func findGarbage(client *predixinsights.Client, now time.Time, minAge time.Duration) ([]garbage, error) {
+	flows, err := client.GetAllFlows(maxFlowPages)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := client.GetAllFlowTemplatesByPage(maxTemplatePages)
+	if err != nil {
+		return nil, err
+	}
+	candidates := []garbage{}
+	// remaining counts the flows each template keeps, expired the expired flows gc deletes
+	remaining, expired := map[string]int{}, map[string]int{}
+	for _, f := range flows {
+		expiry, ok, err := flowExpiry(f)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: flow %s (%s) err=%s\n", f.Name, f.ID, err.Error())
+		}
+		if !ok || expiry.After(now) {
+			remaining[f.FlowTemplate.ID]++
+			continue
+		}
+		g := garbage{kind: "flow", name: f.Name, id: f.ID, templateID: f.FlowTemplate.ID, reason: "expired " + expiry.Format(time.RFC3339)}
+		if status := f.LatestInstanceDetails.Summary.Status; !instanceDone(status) {
+			g.skip = "instance " + status
+			remaining[f.FlowTemplate.ID]++
+		} else {
+			expired[f.FlowTemplate.ID]++
+		}
+		candidates = append(candidates, g)
+	}
+	// only templates gc is responsible for are deleted, an empty template may be about to get its first flow
+	for _, t := range templates {
+		if remaining[t.ID] > 0 {
+			continue
+		}
+		expiry, ok, err := ttlExpiry(t.Tags)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: flow template %s (%s) err=%s\n", t.Name, t.ID, err.Error())
+		}
+		var g garbage
+		switch {
+		case ok && !expiry.After(now):
+			g = garbage{kind: "flow-template", name: t.Name, id: t.ID, reason: "expired " + expiry.Format(time.RFC3339)}
+		case expired[t.ID] > 0:
+			g = garbage{kind: "flow-template", name: t.Name, id: t.ID, reason: "no flows left after the expired ones"}
+		default:
+			continue
+		}
+		if created := time.Unix(0, t.Created*int64(time.Millisecond)); minAge > 0 && now.Sub(created) < minAge {
+			g.skip = "created " + created.UTC().Format(time.RFC3339)
+		}
+		candidates = append(candidates, g)
+	}
+	return candidates, nil
+}
+
+// flowExpiry reads the pi-ttl tag of f, ok is false for flows without one
+func flowExpiry(f predixinsights.Flow) (expiry time.Time, ok bool, err error) {
+	return ttlExpiry(flowTags(f.Tags))
+}
+
+// ttlExpiry returns the expiry of the pi-ttl tag in tags, if any
+func ttlExpiry(tags []string) (expiry time.Time, ok bool, err error) {
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, ttlTagPrefix) {
+			continue
+		}
+		expiry, err = time.Parse(time.RFC3339, strings.TrimPrefix(tag, ttlTagPrefix))
+		if err != nil {
+			return expiry, false, fmt.Errorf("invalid tag %s", tag)
+		}
+		return expiry, true, nil
+	}
+	return expiry, false, nil
+}
+
+// instanceDone reports whether the status of the latest instance of a flow is final. The API reports YARN application
+// states and final statuses alike, unknown ones count as running so gc never deletes a flow it cannot tell is idle.
+func instanceDone(status string) bool {
+	switch status {
+	case "", "FINISHED", "SUCCEEDED", "FAILED", "KILLED", "STOPPED":
+		return true
+	}
+	return false
+}
+
+// collectGarbage deletes the candidates that are not skipped and prints the report, returning the number of failures.
+// A flow template is kept when one of its expired flows could not be deleted.
+func collectGarbage(client *predixinsights.Client, candidates []garbage) int {
+	deleted, skipped, failed := 0, 0, 0
+	kept := map[string]bool{}
+	for _, c := range candidates {
+		if c.kind == "flow-template" && kept[c.id] {
+			c.skip = "flow not deleted"
+		}
+		if c.skip != "" {
+			skipped++
+			c.report("")
+			continue
+		}
+		var err error
+		if c.kind == "flow" {
+			err = client.DeleteFlowByFlowIDOnly(c.id)
+		} else {
+			err = client.DeleteFlowTemplate(c.id)
+		}
+		if err != nil {
+			failed++
+			kept[c.templateID] = true
+			c.report("failed")
+			fmt.Println("  err=" + err.Error())
+			continue
+		}
+		deleted++
+		c.report("deleted")
+	}
+	fmt.Printf("gc: %d deleted, %d skipped, %d failed\n", deleted, skipped, failed)
+	return failed
+}
+
+// ttlTags returns the pi-ttl tag for a flow created now with the given ttl, none when ttl is empty
+func ttlTags(ttl string) ([]string, error) {
+	if ttl == "" {
+		return nil, nil
+	}
+	d, err := time.ParseDuration(ttl)
+	if err != nil {
+		return nil, err
+	}
+	if d <= 0 {
+		return nil, errors.New("ttl must be positive")
+	}
+	return []string{ttlTagPrefix + time.Now().Add(d).UTC().Format(time.RFC3339)}, nil
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+)
+
+func TestFindGarbage(t *testing.T) {
+	now := time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)
+	expired, valid := ttlTagPrefix+"2030-01-01T00:00:00Z", ttlTagPrefix+"2030-01-03T00:00:00Z"
+	tenant := newFakeTenant(t)
+	withExpired := tenant.addTemplate(predixinsights.FlowTemplate{Name: "with-expired"})
+	tenant.addFlow(withExpired, "expired").Tags = []interface{}{expired}
+	mixed := tenant.addTemplate(predixinsights.FlowTemplate{Name: "mixed"})
+	tenant.addFlow(mixed, "expired-in-mixed").Tags = []interface{}{expired}
+	tenant.addFlow(mixed, "valid").Tags = []interface{}{valid}
+	tenant.addFlow(mixed, "untagged")
+	running := tenant.addTemplate(predixinsights.FlowTemplate{Name: "running"})
+	for status, name := range map[string]string{"RUNNING": "running", "ACCEPTED": "accepted", "UNKNOWN": "unknown", "SUCCEEDED": "succeeded", "KILLED": "killed"} {
+		f := tenant.addFlow(running, name)
+		f.Tags = []interface{}{expired}
+		f.LatestInstanceDetails.Summary.Status = status
+	}
+	tenant.addTemplate(predixinsights.FlowTemplate{Name: "empty"})
+	tenant.addTemplate(predixinsights.FlowTemplate{Name: "expired-template", Tags: []string{expired}})
+	tenant.addTemplate(predixinsights.FlowTemplate{Name: "valid-template", Tags: []string{valid}})
+	server := httptest.NewServer(tenant)
+	defer server.Close()
+
+	candidates, err := findGarbage(&predixinsights.Client{APIHost: server.URL}, now, 0)
+	if err != nil {
+		t.Fatalf("err=%s", err.Error())
+	}
+	got := map[string]string{}
+	for _, c := range candidates {
+		got[c.kind+"/"+c.name] = c.skip
+	}
+	want := map[string]string{
+		"flow/expired":                   "",
+		"flow/expired-in-mixed":          "",
+		"flow/running":                   "instance RUNNING",
+		"flow/accepted":                  "instance ACCEPTED",
+		"flow/unknown":                   "instance UNKNOWN",
+		"flow/succeeded":                 "",
+		"flow/killed":                    "",
+		"flow-template/with-expired":     "",
+		"flow-template/expired-template": "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,384 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// har is the root of an HTTP Archive 1.2 document
+type har struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string         `json:"mimeType"`
+	Text     string         `json:"text,omitempty"`
+	Params   []harPostParam `json:"params,omitempty"`
+}
+
+type harPostParam struct {
+	Name     string `json:"name"`
+	Value    string `json:"value,omitempty"`
+	FileName string `json:"fileName,omitempty"`
+	Size     int    `json:"size,omitempty"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harRecorder is a http.RoundTripper writing every exchange, redacted, to a HAR file
+type harRecorder struct {
+	next http.RoundTripper
+	path string
+	mux  sync.Mutex
+	har  har
+}
+
+func newHARRecorder(next http.RoundTripper, path string) *harRecorder {
+	return &harRecorder{
+		next: next,
+		path: path,
+		har:  har{Log: harLog{Version: "1.2", Creator: harCreator{Name: "pi", Version: Version}, Entries: []harEntry{}}},
+	}
+}
+
+func (r *harRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	res, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+	if err != nil {
+		return nil, err
+	}
+	elapsed := float64(time.Since(start)) / float64(time.Millisecond)
+
+	entry := harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Time:            elapsed,
+		Request:         newHARRequest(req, reqBody),
+		Response:        newHARResponse(res, resBody),
+		Timings:         harTimings{Send: 0, Wait: elapsed, Receive: 0},
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.har.Log.Entries = append(r.har.Log.Entries, entry)
+	// rewrite the whole archive so the capture survives early exits
+	b, err := json.MarshalIndent(&r.har, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(r.path, b, os.FileMode(0600)); err != nil {
+		return nil, errors.New("error writing HAR file err=" + err.Error())
+	}
+	return res, nil
+}
+
+// readRequestBody returns a copy of the request body without consuming it
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(body)
+	}
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+// harHeaders lists redacted headers sorted by name, recordings of the same session are identical
+func harHeaders(h http.Header) []harNameValue {
+	nvs := []harNameValue{}
+	h = redactHeader(h)
+	for _, k := range sortedKeys(h) {
+		for _, v := range h[k] {
+			nvs = append(nvs, harNameValue{Name: k, Value: v})
+		}
+	}
+	return nvs
+}
+
+// harQueryString lists query parameters sorted by name, with tokens, secrets and registered values redacted as in URLs
+func harQueryString(u *url.URL) []harNameValue {
+	nvs := []harNameValue{}
+	query := u.Query()
+	for _, k := range sortedKeys(query) {
+		for _, v := range query[k] {
+			if redactedFormFields.MatchString(k + "=") {
+				v = redactedValue
+			}
+			nvs = append(nvs, harNameValue{Name: k, Value: string(redactBody([]byte(v)))})
+		}
+	}
+	return nvs
+}
+
+func newHARRequest(req *http.Request, body []byte) harRequest {
+	query := harQueryString(req.URL)
+	hr := harRequest{
+		Method:      strings.ToUpper(req.Method),
+		URL:         redactURL(req.URL.String()),
+		HTTPVersion: "HTTP/1.1",
+		Headers:     harHeaders(req.Header),
+		QueryString: query,
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	if len(body) > 0 {
+		hr.PostData = newHARPostData(req.Header.Get("Content-Type"), body)
+	}
+	return hr
+}
+
+// newHARPostData records multipart uploads as parameters, never the uploaded file contents
+func newHARPostData(contentType string, body []byte) *harPostData {
+	pd := &harPostData{MimeType: contentType}
+	parts, err := multipartSummary(contentType, body)
+	if err != nil {
+		pd.Text = string(redactBody(body))
+		return pd
+	}
+	for _, p := range parts {
+		param := harPostParam{Name: p.name, FileName: p.fileName}
+		if p.fileName == "" {
+			param.Value = string(redactBody(p.value))
+		} else {
+			param.Size = p.size
+		}
+		pd.Params = append(pd.Params, param)
+	}
+	return pd
+}
+
+type multipartPart struct {
+	name     string
+	fileName string
+	size     int
+	value    []byte
+}
+
+// multipartSummary lists the parts of a multipart body, it fails for other content types
+func multipartSummary(contentType string, body []byte) ([]multipartPart, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, errors.New("not a multipart body")
+	}
+	parts := []multipartPart{}
+	mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return parts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		value, err := ioutil.ReadAll(p)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, multipartPart{name: p.FormName(), fileName: p.FileName(), size: len(value), value: value})
+	}
+}
+
+func newHARResponse(res *http.Response, body []byte) harResponse {
+	content := harContent{Size: len(body), MimeType: res.Header.Get("Content-Type")}
+	if utf8.Valid(body) {
+		content.Text = string(redactBody(body))
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString(body)
+		content.Encoding = "base64"
+	}
+	return harResponse{
+		Status:      res.StatusCode,
+		StatusText:  http.StatusText(res.StatusCode),
+		HTTPVersion: "HTTP/1.1",
+		Headers:     harHeaders(res.Header),
+		Content:     content,
+		RedirectURL: res.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+}
+
+// harReplayer is a http.RoundTripper answering requests from a HAR file instead of the network
+type harReplayer struct {
+	mux     sync.Mutex
+	entries map[string][]harEntry
+	served  map[string]int
+}
+
+func newHARReplayer(path string) (*harReplayer, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	h := har{}
+	if err := json.Unmarshal(b, &h); err != nil {
+		return nil, errors.New("invalid HAR file " + path + " err=" + err.Error())
+	}
+	r := &harReplayer{entries: map[string][]harEntry{}, served: map[string]int{}}
+	for _, e := range h.Log.Entries {
+		key, err := replayKey(e.Request.Method, e.Request.URL)
+		if err != nil {
+			return nil, err
+		}
+		r.entries[key] = append(r.entries[key], e)
+	}
+	return r, nil
+}
+
+// replayKey matches requests by method, path and query so captures replay against any API host
+func replayKey(method, rawURL string) (string, error) {
+	i := strings.Index(rawURL, "://")
+	if i >= 0 {
+		rawURL = rawURL[i+3:]
+		if j := strings.IndexAny(rawURL, "/?"); j >= 0 {
+			rawURL = rawURL[j:]
+		} else {
+			rawURL = "/"
+		}
+	}
+	if strings.Contains(rawURL, "grant_type=") {
+		// UAA token requests depend on the issuer, not the API host
+		return "TOKEN", nil
+	}
+	return strings.ToUpper(method) + " " + redactURL(rawURL), nil
+}
+
+func (r *harReplayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	key, err := replayKey(req.Method, req.URL.String())
+	if err != nil {
+		return nil, err
+	}
+	r.mux.Lock()
+	entries := r.entries[key]
+	if len(entries) == 0 {
+		r.mux.Unlock()
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, redactURL(req.URL.String()))
+	}
+	// serve recorded responses in order, repeating the last one for polling loops
+	i := r.served[key]
+	if i >= len(entries) {
+		i = len(entries) - 1
+	}
+	r.served[key]++
+	e := entries[i]
+	r.mux.Unlock()
+
+	body := []byte(e.Response.Content.Text)
+	if e.Response.Content.Encoding == "base64" {
+		body, err = base64.StdEncoding.DecodeString(e.Response.Content.Text)
+		if err != nil {
+			return nil, err
+		}
+	}
+	header := http.Header{}
+	for _, h := range e.Response.Headers {
+		header.Add(h.Name, h.Value)
+	}
+	header.Set("Content-Length", fmt.Sprintf("%d", len(body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Response.Status, e.Response.StatusText),
+		StatusCode:    e.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// readFlagValue returns the value of a flag given inline, as @path for the content of a file or as - for stdin
+func readFlagValue(value string) ([]byte, error) {
+	switch {
+	case value == "-":
+		return ioutil.ReadAll(os.Stdin)
+	case strings.HasPrefix(value, "@"):
+		return ioutil.ReadFile(strings.TrimPrefix(value, "@"))
+	}
+	return []byte(value), nil
+}
+
+// decodeJSONFlag decodes the JSON or YAML value of flag name of p into v, see readFlagValue. Unknown fields are rejected,
+// errors name the flag and the location of the offending value. A value read from stdin replaces - in p, so it is not read
+// from stdin again when the flag is taken from the config.
+func decodeJSONFlag(p pi, name string, v interface{}) error {
+	value := p.V.GetString(name)
+	source := "--" + name
+	if value == "-" || strings.HasPrefix(value, "@") {
+		source += " " + value
+	}
+	b, err := readFlagValue(value)
+	if err != nil {
+		return fmt.Errorf("%s: %s", source, err.Error())
+	}
+	if err := decodeDocument(b, v); err != nil {
+		return fmt.Errorf("%s: %s", source, err.Error())
+	}
+	if value == "-" {
+		p.V.Set(name, string(b))
+	}
+	return nil
+}
+
+// decodeDocument decodes a JSON or YAML document into v, rejecting unknown fields with their path
+func decodeDocument(b []byte, v interface{}) error {
+	j, err := yamlToJSON(b)
+	if err != nil {
+		return err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(j, &doc); err != nil {
+		return err
+	}
+	if path := unknownField(doc, reflect.TypeOf(v), ""); path != "" {
+		return fmt.Errorf("unknown field at %s", path)
+	}
+	dec := json.NewDecoder(bytes.NewReader(j))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		if t, ok := err.(*json.UnmarshalTypeError); ok {
+			return fmt.Errorf("expected %s at %s, got %s", t.Type, orDefault(t.Field, "the top level"), t.Value)
+		}
+		return err
+	}
+	return nil
+}
+
+// unknownField returns the path of the first key of doc without a field in t, matched like encoding/json does
+func unknownField(doc interface{}, t reflect.Type, path string) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Map:
+			for _, k := range sortedKeys(d) {
+				if p := unknownField(d[k], t.Elem(), joinPath(path, k)); p != "" {
+					return p
+				}
+			}
+		case reflect.Struct:
+			for _, k := range sortedKeys(d) {
+				f, ok := jsonField(t, k)
+				if !ok {
+					return joinPath(path, k)
+				}
+				if p := unknownField(d[k], f.Type, joinPath(path, k)); p != "" {
+					return p
+				}
+			}
+		}
+	case []interface{}:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, e := range d {
+				if p := unknownField(e, t.Elem(), path+"["+strconv.Itoa(i)+"]"); p != "" {
+					return p
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// jsonField finds the exported field of t encoding/json decodes key into
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if strings.EqualFold(name, key) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// lockWait bounds how long a command waits for another process holding the same lock
+	lockWait = 2 * time.Minute
+	// lockPoll is the time between attempts to take a held lock
+	lockPoll = 500 * time.Millisecond
+)
+
+// localLock is a lock file in ~/.pi/locks, it records the holder and what is needed to recover when the holder dies
+type localLock struct {
+	path string
+	PID  int             `json:"pid"`
+	Time string          `json:"time"`
+	Note string          `json:"note"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// acquireLock takes the lock name, waiting for a live holder to release it. A lock left by a dead process is not
+// taken over, its file may hold state to restore by hand. A dry run changes nothing and writes no lock file.
+func acquireLock(name, note string) (*localLock, error) {
+	l := &localLock{path: filepath.Join(dir, "locks", safeFileName(name)+".lock"), PID: os.Getpid(), Note: note}
+	if viper.GetBool("dry-run") {
+		l.path = ""
+		return l, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), os.ModePerm); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(lockWait)
+	for {
+		l.Time = time.Now().UTC().Format(time.RFC3339)
+		b, err := json.MarshalIndent(l, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(b, '\n'))
+			f.Close()
+			if err != nil {
+				os.Remove(l.path)
+				return nil, err
+			}
+			return l, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		holder := &localLock{}
+		if b, err := ioutil.ReadFile(l.path); err == nil {
+			json.Unmarshal(b, holder)
+		}
+		if holder.PID > 0 && !processAlive(holder.PID) {
+			return nil, fmt.Errorf("%s was left by process %d (%s) at %s, check the state it recorded and delete it", l.path, holder.PID, holder.Note, holder.Time)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is held by process %d (%s) since %s", l.path, holder.PID, holder.Note, holder.Time)
+		}
+		time.Sleep(lockPoll)
+	}
+}
+
+// record stores data in the lock file for recovery should the holder die
+func (l *localLock) record(data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	l.Data = b
+	if l.path == "" {
+		return nil
+	}
+	b, err = json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(l.path, append(b, '\n'), 0644)
+}
+
+func (l *localLock) release() error {
+	if l.path == "" {
+		return nil
+	}
+	return os.Remove(l.path)
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import "syscall"
+
+// processAlive reports whether a process with the pid exists, signal 0 only checks for it
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
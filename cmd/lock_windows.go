@@ -0,0 +1,27 @@
+//go:build windows
+// +build windows
+
+package cmd
+
+import "syscall"
+
+const (
+	processQueryLimitedInformation = 0x1000
+	// stillActive is the exit code of a process that has not exited
+	stillActive = 259
+)
+
+// processAlive reports whether a process with the pid exists and has not exited
+func processAlive(pid int) bool {
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		// the process exists but belongs to another user
+		return err == syscall.ERROR_ACCESS_DENIED
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return true
+	}
+	return code == stillActive
+}
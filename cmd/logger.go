@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+const (
+	// logInfo logs the method, URL, status and duration of every request (-v)
+	logInfo = 1
+	// logHeaders additionally logs redacted request and response headers (-vv)
+	logHeaders = 2
+	// logBodies additionally logs redacted and truncated request and response bodies (-vvv)
+	logBodies = 3
+)
+
+// redactedValue replaces credentials in logged and recorded output
+const redactedValue = "[REDACTED]"
+
+// maxLoggedMultipartBody is the number of bytes of a multipart upload body that are logged
+const maxLoggedMultipartBody = 1024
+
+var bold = color.New(color.Bold).SprintFunc()
+
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+var redactedJSONFields = regexp.MustCompile(`("(?:access_token|refresh_token|id_token|token|client_secret|password)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+var redactedFormFields = regexp.MustCompile(`((?:^|&)(?:access_token|refresh_token|id_token|token|client_secret|password)=)[^&]*`)
+
+// redactedValues holds values registered with redactValues
+var redactedValues = struct {
+	sync.Mutex
+	values []string
+}{}
+
+// Logger receives the log messages of the CLI, level is one of logInfo, logHeaders and logBodies. Messages above the
+// verbosity given with -v are not passed to it.
+type Logger interface {
+	Logf(level int, format string, args ...interface{})
+}
+
+// stderrLogger is the default Logger, writing one line per message to stderr
+type stderrLogger struct {
+	// mux serializes log lines of concurrent requests
+	mux sync.Mutex
+}
+
+func (l *stderrLogger) Logf(level int, format string, args ...interface{}) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	fmt.Fprintf(os.Stderr, strings.TrimRight(format, "\n")+"\n", args...)
+}
+
+var logger Logger = &stderrLogger{}
+
+// SetLogger replaces the stderr logger, nil restores it
+func SetLogger(l Logger) {
+	if l == nil {
+		l = &stderrLogger{}
+	}
+	logger = l
+}
+
+// redactHeader returns a copy of h with credential headers replaced
+func redactHeader(h http.Header) http.Header {
+	redacted := http.Header{}
+	for k, v := range h {
+		redacted[k] = append([]string{}, v...)
+	}
+	for _, k := range redactedHeaders {
+		if _, ok := redacted[k]; ok {
+			redacted.Set(k, redactedValue)
+		}
+		// the SDK adds lower case headers directly in a few places
+		if _, ok := redacted[strings.ToLower(k)]; ok {
+			redacted[strings.ToLower(k)] = []string{redactedValue}
+		}
+	}
+	return redacted
+}
+
+// redactValues registers values, such as secrets rendered into an upload, that redactBody replaces wherever they appear
+func redactValues(values ...string) {
+	redactedValues.Lock()
+	defer redactedValues.Unlock()
+	for _, v := range values {
+		if v != "" {
+			redactedValues.values = append(redactedValues.values, v)
+		}
+	}
+}
+
+// redactBody returns a copy of b with token and secret fields and the values registered with redactValues replaced
+func redactBody(b []byte) []byte {
+	b = redactedJSONFields.ReplaceAll(b, []byte(`${1}"`+redactedValue+`"`))
+	b = redactedFormFields.ReplaceAll(b, []byte("${1}"+redactedValue))
+	redactedValues.Lock()
+	defer redactedValues.Unlock()
+	for _, v := range redactedValues.values {
+		b = bytes.Replace(b, []byte(v), []byte(redactedValue), -1)
+	}
+	return b
+}
+
+// redactURL returns rawURL with token and secret query parameters replaced
+func redactURL(rawURL string) string {
+	i := strings.Index(rawURL, "?")
+	if i < 0 {
+		return rawURL
+	}
+	return rawURL[:i+1] + string(redactedFormFields.ReplaceAll([]byte(rawURL[i+1:]), []byte("${1}"+redactedValue)))
+}
+
+// logf passes a message to the logger if the verbosity is at least level
+func logf(level int, format string, args ...interface{}) {
+	if verbosity() < level {
+		return
+	}
+	logger.Logf(level, format, args...)
+}
+
+// logTransport is a http.RoundTripper logging redacted exchanges through the logger for -v, -vv and -vvv
+type logTransport struct {
+	next http.RoundTripper
+}
+
+func (t *logTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if verbosity() < logInfo {
+		return t.next.RoundTrip(req)
+	}
+	logRequest(req)
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		logf(logInfo, "%s %s %s failed after %s: %v", bold("RESPONSE:"), req.Method, redactURL(req.URL.String()), time.Since(start), err)
+		return nil, err
+	}
+	logResponse(res, time.Since(start))
+	return res, nil
+}
+
+func logRequest(req *http.Request) {
+	logf(logInfo, "%s %s %s", bold("REQUEST:"), req.Method, redactURL(req.URL.String()))
+	if len(req.Header) > 0 {
+		logf(logHeaders, "%s", formatHeader(">", req.Header))
+	}
+	if verbosity() >= logBodies && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return
+		}
+		defer body.Close()
+		b, err := ioutil.ReadAll(body)
+		if err == nil && len(b) > 0 {
+			logf(logBodies, "%s\n", truncateBody(req.Header.Get("Content-Type"), redactBody(b)))
+		}
+	}
+}
+
+func logResponse(res *http.Response, elapsed time.Duration) {
+	logf(logInfo, "%s %s %s -> %s (%s)", bold("RESPONSE:"), res.Request.Method, redactURL(res.Request.URL.String()), res.Status, elapsed)
+	if len(res.Header) > 0 {
+		logf(logHeaders, "%s", formatHeader("<", res.Header))
+	}
+	if verbosity() >= logBodies && res.Body != nil {
+		b, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		res.Body = ioutil.NopCloser(bytes.NewReader(b))
+		if err == nil && len(b) > 0 {
+			logf(logBodies, "%s\n", truncateBody(res.Header.Get("Content-Type"), redactBody(b)))
+		}
+	}
+}
+
+func truncateBody(contentType string, b []byte) []byte {
+	if strings.HasPrefix(contentType, "multipart/") && len(b) > maxLoggedMultipartBody {
+		return append(b[:maxLoggedMultipartBody:maxLoggedMultipartBody], []byte(fmt.Sprintf("\n... [%d bytes truncated]", len(b)-maxLoggedMultipartBody))...)
+	}
+	return b
+}
+
+func formatHeader(prefix string, h http.Header) string {
+	h = redactHeader(h)
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	for _, k := range keys {
+		for _, v := range h[k] {
+			fmt.Fprintf(&buf, "%s %s: %s\n", prefix, k, v)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,393 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// reservedTagPrefix marks tags managed by the CLI rather than the user
+	reservedTagPrefix = "pi-"
+	// artifactTagPrefix tags a flow template with the sha256 of its uploaded artifact
+	artifactTagPrefix = "pi-sha256:"
+)
+
+// manifest describes the desired state of a tenant, see pi apply
+type manifest struct {
+	FlowTemplates []manifestFlowTemplate `json:"flowTemplates,omitempty"`
+	Flows         []manifestFlow         `json:"flows,omitempty"`
+	DAGs          []manifestDAG          `json:"dags,omitempty"`
+	Dependencies  []manifestDependency   `json:"dependencies,omitempty"`
+}
+
+// manifestFlowTemplate leaves spark arguments and tags untouched when they are omitted
+type manifestFlowTemplate struct {
+	Name        string                         `json:"name"`
+	Version     string                         `json:"version"`
+	Type        string                         `json:"type"`
+	Description string                         `json:"description,omitempty"`
+	File        string                         `json:"file"`
+	SparkArgs   *predixinsights.SparkArguments `json:"sparkArguments,omitempty"`
+	Tags        []string                       `json:"tags,omitempty"`
+}
+
+// manifestFlow references its flow template by name, omitted config files and tags are left untouched
+type manifestFlow struct {
+	Name        string                         `json:"name"`
+	Template    string                         `json:"template"`
+	SparkArgs   *predixinsights.SparkArguments `json:"sparkArguments,omitempty"`
+	ConfigFiles []manifestConfigFile           `json:"configFiles,omitempty"`
+	Tags        []string                       `json:"tags,omitempty"`
+}
+
+// manifestConfigFile is uploaded as Name, which defaults to the base name of File
+type manifestConfigFile struct {
+	Name string `json:"name,omitempty"`
+	File string `json:"file"`
+}
+
+type manifestDAG struct {
+	Name        string              `json:"name"`
+	Version     string              `json:"version"`
+	Type        string              `json:"type"`
+	Description string              `json:"description,omitempty"`
+	File        string              `json:"file"`
+	Template    manifestDAGTemplate `json:"template"`
+	Deploy      bool                `json:"deploy,omitempty"`
+}
+
+// manifestDAGTemplate holds the values rendered into the DAG file, see predixinsights.DAGTemplate
+type manifestDAGTemplate struct {
+	Owner    string `json:"owner"`
+	FlowName string `json:"flowName"`
+	Interval string `json:"interval"`
+}
+
+// manifestDependency is matched by Name, which defaults to the base name of File
+type manifestDependency struct {
+	Name   string `json:"name,omitempty"`
+	Type   string `json:"type"`
+	File   string `json:"file"`
+	Deploy bool   `json:"deploy,omitempty"`
+}
+
+// loadManifest reads a YAML or JSON manifest, or every *.yaml, *.yml and *.json manifest directly in a directory
+func loadManifest(path string) (*manifest, error) {
+	m, err := readManifests(path)
+	if err != nil {
+		return nil, err
+	}
+	return m, m.check()
+}
+
+// readManifests is loadManifest without validation
+func readManifests(path string) (*manifest, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return readManifest(path)
+	}
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &manifest{}
+	for _, f := range files {
+		ext := filepath.Ext(f.Name())
+		if f.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		part, err := readManifest(filepath.Join(path, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m.FlowTemplates = append(m.FlowTemplates, part.FlowTemplates...)
+		m.Flows = append(m.Flows, part.Flows...)
+		m.DAGs = append(m.DAGs, part.DAGs...)
+		m.Dependencies = append(m.Dependencies, part.Dependencies...)
+	}
+	return m, nil
+}
+
+// readManifest decodes one manifest file, resolving file paths relative to it
+func readManifest(path string) (*manifest, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &manifest{}
+	if err := decodeStrict(b, m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s err=%s", path, err.Error())
+	}
+	base := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(base, p)
+	}
+	for i := range m.FlowTemplates {
+		m.FlowTemplates[i].File = resolve(m.FlowTemplates[i].File)
+	}
+	for i := range m.Flows {
+		for j := range m.Flows[i].ConfigFiles {
+			cf := &m.Flows[i].ConfigFiles[j]
+			if cf.Name == "" {
+				cf.Name = filepath.Base(cf.File)
+			}
+			cf.File = resolve(cf.File)
+		}
+	}
+	for i := range m.DAGs {
+		m.DAGs[i].File = resolve(m.DAGs[i].File)
+	}
+	for i := range m.Dependencies {
+		if m.Dependencies[i].Name == "" {
+			m.Dependencies[i].Name = filepath.Base(m.Dependencies[i].File)
+		}
+		m.Dependencies[i].File = resolve(m.Dependencies[i].File)
+	}
+	return m, nil
+}
+
+func (m *manifest) check() error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+	return m.checkFiles()
+}
+
+// checkFiles fails early on artifacts and config files that cannot be read
+func (m *manifest) checkFiles() error {
+	files := []string{}
+	for _, ft := range m.FlowTemplates {
+		files = append(files, ft.File)
+	}
+	for _, f := range m.Flows {
+		for _, cf := range f.ConfigFiles {
+			files = append(files, cf.File)
+		}
+	}
+	for _, d := range m.DAGs {
+		files = append(files, d.File)
+	}
+	for _, d := range m.Dependencies {
+		files = append(files, d.File)
+	}
+	for _, f := range files {
+		if _, err := os.Stat(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *manifest) validate() error {
+	seen := map[string]bool{}
+	// fields holds pairs of field name and value that must not be empty
+	// key identifies the resource, flow names are unique per flow template only
+	check := func(kind, name, key string, fields ...string) error {
+		if name == "" {
+			return fmt.Errorf("%s without a name", kind)
+		}
+		if seen[kind+"/"+key] {
+			return fmt.Errorf("duplicate %s '%s'", kind, key)
+		}
+		seen[kind+"/"+key] = true
+		for i := 0; i+1 < len(fields); i += 2 {
+			if fields[i+1] == "" {
+				return fmt.Errorf("%s '%s' is missing %s", kind, name, fields[i])
+			}
+		}
+		return nil
+	}
+	for _, ft := range m.FlowTemplates {
+		if err := check("flow-template", ft.Name, ft.Name, "file", ft.File, "version", ft.Version, "type", ft.Type); err != nil {
+			return err
+		}
+	}
+	for _, f := range m.Flows {
+		if err := check("flow", f.Name, flowKey(f.Template, f.Name), "template", f.Template); err != nil {
+			return err
+		}
+		for _, cf := range f.ConfigFiles {
+			if cf.File == "" {
+				return fmt.Errorf("flow '%s' has a config file without a file", f.Name)
+			}
+		}
+	}
+	for _, d := range m.DAGs {
+		if err := check("dag", d.Name, d.Name, "file", d.File, "version", d.Version, "type", d.Type); err != nil {
+			return err
+		}
+	}
+	for _, d := range m.Dependencies {
+		if err := check("dependency", d.Name, d.Name, "file", d.File, "type", d.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeStrict decodes YAML or JSON into v using v's json tags, rejecting unknown fields
+func decodeStrict(b []byte, v interface{}) error {
+	j, err := yamlToJSON(b)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(j))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// yamlToJSON converts a YAML document, JSON included, to JSON
+func yamlToJSON(b []byte) ([]byte, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, errors.New("document is empty")
+	}
+	return json.Marshal(jsonValue(v))
+}
+
+// jsonToYAML converts JSON to YAML, keeping the order of object keys
+func jsonToYAML(b []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	v, err := yamlValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(v)
+}
+
+func yamlValue(dec *json.Decoder) (interface{}, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := t.(type) {
+	case json.Delim:
+		if t == '{' {
+			m := yaml.MapSlice{}
+			for dec.More() {
+				k, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				v, err := yamlValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				m = append(m, yaml.MapItem{Key: k, Value: v})
+			}
+			_, err := dec.Token()
+			return m, err
+		}
+		s := []interface{}{}
+		for dec.More() {
+			v, err := yamlValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, v)
+		}
+		_, err := dec.Token()
+		return s, err
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		return t.Float64()
+	}
+	return t, nil
+}
+
+// jsonValue replaces the map[interface{}]interface{} values yaml.v2 produces with JSON objects
+func jsonValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for k, v := range t {
+			m[fmt.Sprint(k)] = jsonValue(v)
+		}
+		return m
+	case []interface{}:
+		for i := range t {
+			t[i] = jsonValue(t[i])
+		}
+		return t
+	}
+	return v
+}
+
+func fileSHA256(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// userTags drops the tags reserved for the CLI
+func userTags(tags []string) []string {
+	user := []string{}
+	for _, t := range tags {
+		if !strings.HasPrefix(t, reservedTagPrefix) {
+			user = append(user, t)
+		}
+	}
+	return user
+}
+
+// artifactTag returns the artifact sha256 recorded in tags, if any
+func artifactTag(tags []string) string {
+	for _, t := range tags {
+		if strings.HasPrefix(t, artifactTagPrefix) {
+			return strings.TrimPrefix(t, artifactTagPrefix)
+		}
+	}
+	return ""
+}
+
+// mergeTags combines the desired user tags with the reserved tags in current, recording sum when given
+func mergeTags(desired, current []string, sum string) predixinsights.TagsArray {
+	if desired == nil {
+		desired = userTags(current)
+	}
+	tags := predixinsights.TagsArray(append([]string{}, desired...))
+	for _, t := range current {
+		if strings.HasPrefix(t, reservedTagPrefix) && !(sum != "" && strings.HasPrefix(t, artifactTagPrefix)) {
+			tags = append(tags, t)
+		}
+	}
+	if sum != "" {
+		tags = append(tags, artifactTagPrefix+sum)
+	}
+	return tags
+}
+
+// flowTags converts the untyped tags of a flow response
+func flowTags(tags []interface{}) []string {
+	s := []string{}
+	for _, t := range tags {
+		s = append(s, fmt.Sprint(t))
+	}
+	return s
+}
@@ -0,0 +1,687 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+)
+
+const (
+	actionCreate  = "create"
+	actionUpdate  = "update"
+	actionReplace = "replace"
+	actionDelete  = "delete"
+	actionNoOp    = "no-op"
+)
+
+// maxFlowPages bounds the pages of flows read while planning
+const maxFlowPages = 100
+
+// fieldDiff is one changed field of a resource, Op is +, - or ~
+type fieldDiff struct {
+	Op   string `json:"op"`
+	Path string `json:"path"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+func (d fieldDiff) String() string {
+	switch {
+	case d.Op == "~" && d.Old == "":
+		return fmt.Sprintf("~ %s: %s", d.Path, d.New)
+	case d.Op == "~":
+		return fmt.Sprintf("~ %s: %s -> %s", d.Path, d.Old, d.New)
+	case d.Op == "+" && d.New != "":
+		return fmt.Sprintf("+ %s: %s", d.Path, d.New)
+	case d.Op == "-" && d.Old != "":
+		return fmt.Sprintf("- %s: %s", d.Path, d.Old)
+	}
+	return d.Op + " " + d.Path
+}
+
+// resourceChange is the planned change to one resource and how to make it
+type resourceChange struct {
+	Action string      `json:"action"`
+	Kind   string      `json:"kind"`
+	Name   string      `json:"name"`
+	Diffs  []fieldDiff `json:"diffs,omitempty"`
+	apply  func() error
+}
+
+// applyPlan holds the changes reconciling a tenant to a manifest, in the order they are applied
+type applyPlan struct {
+	Changes     []resourceChange
+	client      *predixinsights.Client
+	templateIDs map[string]string
+	// flows by flowKey, flow names are unique per flow template only
+	flows map[string]predixinsights.Flow
+}
+
+// flowKey identifies a flow by the name of its flow template and its name
+func flowKey(template, name string) string {
+	return template + "/" + name
+}
+
+// newApplyPlan compares the manifest to the tenant, prune also deletes flows of the manifest's templates it does not list
+func newApplyPlan(client *predixinsights.Client, m *manifest, prune bool) (*applyPlan, error) {
+	if err := m.enforcePolicy(); err != nil {
+		return nil, err
+	}
+	p := &applyPlan{client: client, templateIDs: map[string]string{}, flows: map[string]predixinsights.Flow{}}
+	if len(m.Dependencies) > 0 {
+		deps, err := client.GetAllDependencies()
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range m.Dependencies {
+			p.planDependency(d, deps.Content)
+		}
+	}
+	for _, ft := range m.FlowTemplates {
+		if err := p.planFlowTemplate(ft); err != nil {
+			return nil, err
+		}
+	}
+	if len(m.Flows) > 0 || prune {
+		flows, err := client.GetAllFlows(maxFlowPages)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range flows {
+			p.flows[flowKey(f.FlowTemplate.Name, f.Name)] = f
+		}
+		for _, f := range m.Flows {
+			if err := p.planFlow(f); err != nil {
+				return nil, err
+			}
+		}
+		if prune {
+			p.planPrune(m)
+		}
+	}
+	if len(m.DAGs) > 0 {
+		dags, err := client.GetAllDAGs()
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range m.DAGs {
+			if err := p.planDAG(d, dags.Content); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return p, nil
+}
+
+func (p *applyPlan) add(c resourceChange) {
+	if c.Action == "" {
+		c.Action = actionNoOp
+		if len(c.Diffs) > 0 {
+			c.Action = actionUpdate
+		}
+	}
+	if c.Action == actionNoOp {
+		c.apply = nil
+	}
+	p.Changes = append(p.Changes, c)
+}
+
+// pending returns the number of changes that would modify the tenant
+func (p *applyPlan) pending() int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Action != actionNoOp {
+			n++
+		}
+	}
+	return n
+}
+
+// print writes the plan as a Terraform style structural diff
+func (p *applyPlan) print(w io.Writer) {
+	symbols := map[string]string{actionCreate: "+", actionUpdate: "~", actionReplace: "-/+", actionDelete: "-", actionNoOp: "="}
+	verbs := map[string]string{actionCreate: "will be created", actionUpdate: "will be updated in-place", actionReplace: "must be replaced", actionDelete: "will be deleted", actionNoOp: "is up to date"}
+	counts := map[string]int{}
+	for _, c := range p.Changes {
+		counts[c.Action]++
+		fmt.Fprintf(w, "%s %s %q %s\n", symbols[c.Action], c.Kind, c.Name, verbs[c.Action])
+		for _, d := range c.Diffs {
+			fmt.Fprintf(w, "    %s\n", d)
+		}
+	}
+	fmt.Fprintf(w, "\nPlan: %d to create, %d to update, %d to replace, %d to delete, %d unchanged.\n", counts[actionCreate], counts[actionUpdate], counts[actionReplace], counts[actionDelete], counts[actionNoOp])
+}
+
+// execute applies the planned changes in order, stopping at the first error
+func (p *applyPlan) execute(w io.Writer) error {
+	for _, c := range p.Changes {
+		if err := c.run(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// executeAll applies every planned change in order, returning the errors of those that failed
+func (p *applyPlan) executeAll(w io.Writer) []error {
+	errs := []error{}
+	for _, c := range p.Changes {
+		if err := c.run(w); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func (c resourceChange) run(w io.Writer) error {
+	if c.apply == nil {
+		return nil
+	}
+	if err := c.apply(); err != nil {
+		return fmt.Errorf("failed to %s %s '%s' err=%s", c.Action, c.Kind, c.Name, err.Error())
+	}
+	fmt.Fprintf(w, "%s %q: %s complete\n", c.Kind, c.Name, c.Action)
+	return nil
+}
+
+func (p *applyPlan) planFlowTemplate(mt manifestFlowTemplate) error {
+	sum, err := fileSHA256(mt.File)
+	if err != nil {
+		return fmt.Errorf("flow-template '%s' artifact err=%s", mt.Name, err.Error())
+	}
+	res, err := p.client.GetFlowTemplateByName(mt.Name)
+	if err != nil {
+		return err
+	}
+	var current *predixinsights.FlowTemplate
+	for i := range res.Content {
+		if res.Content[i].Name == mt.Name {
+			current = &res.Content[i]
+		}
+	}
+
+	c := resourceChange{Kind: "flow-template", Name: mt.Name}
+	if current == nil {
+		// the ID is known once the template is created
+		p.templateIDs[mt.Name] = ""
+		c.Action = actionCreate
+		c.Diffs = append(c.Diffs, added("version", quote(mt.Version)), added("type", quote(mt.Type)), added("artifact", quote(artifactTagPrefix+sum)))
+		if mt.Description != "" {
+			c.Diffs = append(c.Diffs, added("description", quote(mt.Description)))
+		}
+		if mt.SparkArgs != nil {
+			c.Diffs = append(c.Diffs, sparkArgsDiff(predixinsights.SparkArguments{}, *mt.SparkArgs)...)
+		}
+		c.Diffs = append(c.Diffs, tagsDiff(nil, mt.Tags)...)
+		c.apply = func() error {
+			ft, err := p.client.PostFlowTemplate(mt.Name, filepath.Base(mt.File), mt.File, mt.Version, mt.Description, mt.Type)
+			if err != nil {
+				return err
+			}
+			p.templateIDs[mt.Name] = ft.ID
+			return p.updateFlowTemplate(ft.ID, mt, false, mt.SparkArgs != nil, true, nil, sum)
+		}
+		p.add(c)
+		return nil
+	}
+
+	p.templateIDs[mt.Name] = current.ID
+	c.Diffs = append(c.Diffs, diffString("version", current.Version, mt.Version)...)
+	c.Diffs = append(c.Diffs, diffString("type", current.Type, mt.Type)...)
+	c.Diffs = append(c.Diffs, diffString("description", current.Description, mt.Description)...)
+	if old := artifactTag(current.Tags); old != sum {
+		oldArtifact := "(unknown)"
+		if old != "" {
+			oldArtifact = quote(artifactTagPrefix + old)
+		}
+		c.Diffs = append(c.Diffs, changed("artifact", oldArtifact, quote(artifactTagPrefix+sum)))
+	}
+	upload := len(c.Diffs) > 0
+	var sparkDiffs, tagDiffs []fieldDiff
+	if mt.SparkArgs != nil {
+		sparkDiffs = sparkArgsDiff(current.SparkArgs, *mt.SparkArgs)
+	}
+	if mt.Tags != nil {
+		tagDiffs = tagsDiff(userTags(current.Tags), mt.Tags)
+	}
+	c.Diffs = append(append(c.Diffs, sparkDiffs...), tagDiffs...)
+	id, currentTags := current.ID, current.Tags
+	// a new artifact may reset the spark arguments, so they are set again after an upload
+	setArgs := len(sparkDiffs) > 0 || (upload && mt.SparkArgs != nil)
+	setTags := upload || len(tagDiffs) > 0
+	c.apply = func() error {
+		return p.updateFlowTemplate(id, mt, upload, setArgs, setTags, currentTags, sum)
+	}
+	p.add(c)
+	return nil
+}
+
+func (p *applyPlan) updateFlowTemplate(id string, mt manifestFlowTemplate, upload, setArgs, setTags bool, currentTags []string, sum string) error {
+	if upload {
+		err := p.client.UpdateFlowTemplateByFlowTemplateIDUsingNewZip(id, mt.Name, filepath.Base(mt.File), mt.File, mt.Version, mt.Description, mt.Type)
+		if err != nil {
+			return err
+		}
+	}
+	if setArgs {
+		err := p.client.UpdateFlowTemplateByFlowTemplateIDChangeSparkArguments(id, predixinsights.EncapsulatedSparkArgs{SparkArgs: *mt.SparkArgs})
+		if err != nil {
+			return err
+		}
+	}
+	if setTags {
+		_, err := p.client.SaveTagsForFlowTemplate(id, mergeTags(mt.Tags, currentTags, sum))
+		return err
+	}
+	return nil
+}
+
+// resolveTemplate finds the ID of a flow template that is not part of the manifest
+func (p *applyPlan) resolveTemplate(name string) error {
+	if _, ok := p.templateIDs[name]; ok {
+		return nil
+	}
+	res, err := p.client.GetFlowTemplateByName(name)
+	if err != nil {
+		return err
+	}
+	for _, ft := range res.Content {
+		if ft.Name == name {
+			p.templateIDs[name] = ft.ID
+			return nil
+		}
+	}
+	return fmt.Errorf("flow template '%s' not found", name)
+}
+
+func (p *applyPlan) planFlow(mf manifestFlow) error {
+	if err := p.resolveTemplate(mf.Template); err != nil {
+		return fmt.Errorf("flow '%s' err=%s", mf.Name, err.Error())
+	}
+	current, exists := p.flows[flowKey(mf.Template, mf.Name)]
+	c := resourceChange{Kind: "flow", Name: mf.Name}
+
+	// flows cannot move between templates, a flow listed under another template is created there and pruned here
+	if !exists {
+		c.Action = actionCreate
+		c.Diffs = append(c.Diffs, added("template", quote(mf.Template)))
+		if mf.SparkArgs != nil {
+			c.Diffs = append(c.Diffs, sparkArgsDiff(predixinsights.SparkArguments{}, *mf.SparkArgs)...)
+		}
+		for _, cf := range mf.ConfigFiles {
+			c.Diffs = append(c.Diffs, added(configFilePath(cf.Name), ""))
+		}
+		c.Diffs = append(c.Diffs, tagsDiff(nil, mf.Tags)...)
+		c.apply = func() error {
+			tid := p.templateIDs[mf.Template]
+			f, err := p.client.PostFlow(mf.Name, tid)
+			if err != nil {
+				return err
+			}
+			return p.updateFlow(tid, f.ID, mf, mf.SparkArgs != nil, mf.ConfigFiles, nil, nil, mf.Tags != nil, nil)
+		}
+		p.add(c)
+		return nil
+	}
+
+	var sparkDiffs, tagDiffs []fieldDiff
+	if mf.SparkArgs != nil {
+		sparkDiffs = sparkArgsDiff(current.SparkArgs, *mf.SparkArgs)
+	}
+	var upload []manifestConfigFile
+	var replace, remove []string
+	if mf.ConfigFiles != nil {
+		var diffs []fieldDiff
+		var err error
+		upload, replace, remove, diffs, err = p.configFilesDiff(current.ID, mf.ConfigFiles)
+		if err != nil {
+			return fmt.Errorf("flow '%s' err=%s", mf.Name, err.Error())
+		}
+		c.Diffs = append(c.Diffs, diffs...)
+	}
+	currentTags := flowTags(current.Tags)
+	if mf.Tags != nil {
+		tagDiffs = tagsDiff(userTags(currentTags), mf.Tags)
+	}
+	c.Diffs = append(append(sparkDiffs, c.Diffs...), tagDiffs...)
+	tid, id := current.FlowTemplate.ID, current.ID
+	c.apply = func() error {
+		return p.updateFlow(tid, id, mf, len(sparkDiffs) > 0, upload, replace, remove, len(tagDiffs) > 0, currentTags)
+	}
+	p.add(c)
+	return nil
+}
+
+// updateFlow applies the changes of a flow. Changed config files in replace are deleted before upload, which uploads
+// them again, the files in remove are deleted only once the upload succeeded.
+func (p *applyPlan) updateFlow(tid, id string, mf manifestFlow, setArgs bool, upload []manifestConfigFile, replace, remove []string, setTags bool, currentTags []string) error {
+	if setArgs {
+		err := p.client.UpdateFlowChangeSparkArguments(tid, id, predixinsights.EncapsulatedSparkArgs{SparkArgs: *mf.SparkArgs})
+		if err != nil {
+			return err
+		}
+	}
+	replaced := []string{}
+	for _, name := range replace {
+		if err := p.client.UpdateFlowByFlowIDDeleteConfigFile(id, name); err != nil {
+			return lostConfigFiles(fmt.Errorf("%s err=%s", name, err.Error()), replaced)
+		}
+		replaced = append(replaced, name)
+	}
+	if len(upload) > 0 {
+		fileDetails := []predixinsights.FileDetails{}
+		for _, cf := range upload {
+			fileDetails = append(fileDetails, predixinsights.FileDetails{FileName: cf.Name, FileLocation: cf.File})
+		}
+		if err := p.client.UpdateFlowByFlowIDAddConfigFile(id, fileDetails); err != nil {
+			return lostConfigFiles(err, replaced)
+		}
+	}
+	for _, name := range remove {
+		if err := p.client.UpdateFlowByFlowIDDeleteConfigFile(id, name); err != nil {
+			return fmt.Errorf("%s err=%s", name, err.Error())
+		}
+	}
+	if setTags {
+		_, err := p.client.SaveTagsForFlow(tid, id, mergeTags(mf.Tags, currentTags, ""))
+		return err
+	}
+	return nil
+}
+
+// configFilesDiff compares config files by name and by content for JSON files, size for others. It returns the files
+// to upload, the changed ones among them to replace and the ones to remove.
+func (p *applyPlan) configFilesDiff(flowID string, desired []manifestConfigFile) ([]manifestConfigFile, []string, []string, []fieldDiff, error) {
+	list, err := p.client.ListConfigFilesByFlowID(flowID)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	sizes := map[string]int{}
+	for _, f := range list {
+		if !f.Directory {
+			sizes[f.FileName] = f.FileSize
+		}
+	}
+	upload, replace, remove, diffs := []manifestConfigFile{}, []string{}, []string{}, []fieldDiff{}
+	wanted := map[string]bool{}
+	for _, cf := range desired {
+		wanted[cf.Name] = true
+		if _, ok := sizes[cf.Name]; !ok {
+			upload = append(upload, cf)
+			diffs = append(diffs, added(configFilePath(cf.Name), ""))
+			continue
+		}
+		differs, err := p.configFileChanged(flowID, cf)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		if differs {
+			upload = append(upload, cf)
+			replace = append(replace, cf.Name)
+			diffs = append(diffs, changed(configFilePath(cf.Name), "", "content changed or not comparable"))
+		}
+	}
+	for _, name := range sortedKeys(sizes) {
+		if !wanted[name] {
+			remove = append(remove, name)
+			diffs = append(diffs, removed(configFilePath(name), ""))
+		}
+	}
+	return upload, replace, remove, diffs, nil
+}
+
+func (p *applyPlan) configFileChanged(flowID string, cf manifestConfigFile) (bool, error) {
+	b, err := ioutil.ReadFile(cf.File)
+	if err != nil {
+		return false, err
+	}
+	return configFileChanged(p.client, flowID, cf.Name, b)
+}
+
+// configFileChanged compares the content b with the config file name of a flow by its JSON content. Other files
+// can only be downloaded as JSON, so they can't be compared and are always reported changed
+func configFileChanged(client *predixinsights.Client, flowID, name string, b []byte) (bool, error) {
+	local := map[string]interface{}{}
+	if json.Unmarshal(b, &local) != nil {
+		return true, nil
+	}
+	remote, err := downloadConfigFile(client, flowID, name)
+	if err != nil {
+		return false, err
+	}
+	return !reflect.DeepEqual(local, remote), nil
+}
+
+func (p *applyPlan) planPrune(m *manifest) {
+	templates, flows := map[string]bool{}, map[string]bool{}
+	for _, ft := range m.FlowTemplates {
+		templates[ft.Name] = true
+	}
+	for _, f := range m.Flows {
+		flows[flowKey(f.Template, f.Name)] = true
+	}
+	for _, key := range sortedKeys(p.flows) {
+		f := p.flows[key]
+		if !templates[f.FlowTemplate.Name] || flows[key] {
+			continue
+		}
+		id := f.ID
+		p.add(resourceChange{Action: actionDelete, Kind: "flow", Name: f.Name, Diffs: []fieldDiff{removed("template", quote(f.FlowTemplate.Name))}, apply: func() error {
+			return p.client.DeleteFlowByFlowIDOnly(id)
+		}})
+	}
+}
+
+// planDAG compares the version, type, description, template owner and interval and the deployment of a DAG. The API
+// does not expose the file or the flow name rendered into it.
+func (p *applyPlan) planDAG(md manifestDAG, current []predixinsights.DAG) error {
+	c := resourceChange{Kind: "dag", Name: md.Name}
+	var cur *predixinsights.DAG
+	for i := range current {
+		if current[i].Name == md.Name {
+			cur = &current[i]
+		}
+	}
+	if cur == nil {
+		c.Action = actionCreate
+		c.Diffs = append(c.Diffs, added("version", quote(md.Version)), added("type", quote(md.Type)), added("file", quote(filepath.Base(md.File))))
+		if md.Description != "" {
+			c.Diffs = append(c.Diffs, added("description", quote(md.Description)))
+		}
+		c.Diffs = append(c.Diffs, added("template.owner", quote(md.Template.Owner)), added("template.flowName", quote(md.Template.FlowName)), added("template.interval", quote(md.Template.Interval)))
+		if md.Deploy {
+			c.Diffs = append(c.Diffs, added("deployed", "true"))
+		}
+		c.apply = func() error {
+			_, err := p.client.PostDAG(md.Name, filepath.Base(md.File), md.File, md.Version, md.Description, md.Type, predixinsights.DAGTemplate(md.Template))
+			if err != nil || !md.Deploy {
+				return err
+			}
+			return p.client.DeployDAG(md.Name)
+		}
+		p.add(c)
+		return nil
+	}
+
+	dag, statusErr, err := readDAG(p.client, *cur)
+	if err != nil {
+		return err
+	}
+	c.Diffs = append(c.Diffs, diffString("version", dag.Version, md.Version)...)
+	c.Diffs = append(c.Diffs, diffString("type", dag.Type, md.Type)...)
+	c.Diffs = append(c.Diffs, diffString("description", dag.Description, md.Description)...)
+	// without a status the template values are unknown, they are not reported as changed
+	if statusErr == nil {
+		c.Diffs = append(c.Diffs, diffString("template.owner", dag.Template.Owner, md.Template.Owner)...)
+		c.Diffs = append(c.Diffs, diffString("template.interval", dag.Template.Interval, md.Template.Interval)...)
+	}
+	upload := len(c.Diffs) > 0
+	deploy := md.Deploy && !cur.Deployed
+	if deploy {
+		c.Diffs = append(c.Diffs, changed("deployed", "false", "true"))
+	}
+	c.apply = func() error {
+		if upload {
+			err := p.client.UpdateDAG(md.Name, filepath.Base(md.File), md.File, md.Version, md.Description, md.Type, predixinsights.DAGTemplate(md.Template))
+			if err != nil {
+				return err
+			}
+		}
+		if deploy {
+			return p.client.DeployDAG(md.Name)
+		}
+		return nil
+	}
+	p.add(c)
+	return nil
+}
+
+func (p *applyPlan) planDependency(md manifestDependency, current []predixinsights.DependencyResponse) {
+	c := resourceChange{Kind: "dependency", Name: md.Name}
+	var cur *predixinsights.DependencyResponse
+	for i := range current {
+		if current[i].Name == md.Name {
+			cur = &current[i]
+		}
+	}
+	post := func() error {
+		res, err := p.client.PostDependency(md.Type, md.Name, md.File)
+		if err != nil || !md.Deploy || len(res) == 0 {
+			return err
+		}
+		return p.client.DeployDependencyByDependencyID(res[0].ID)
+	}
+	switch {
+	case cur == nil:
+		c.Action = actionCreate
+		c.Diffs = append(c.Diffs, added("type", quote(md.Type)))
+		if md.Deploy {
+			c.Diffs = append(c.Diffs, added("deployed", "true"))
+		}
+		c.apply = post
+	case cur.Type != md.Type:
+		c.Action = actionReplace
+		c.Diffs = append(c.Diffs, changed("type", quote(cur.Type), quote(md.Type)))
+		id := cur.ID
+		c.apply = func() error {
+			if err := p.client.DeleteDependencyByID(id); err != nil {
+				return err
+			}
+			return post()
+		}
+	case md.Deploy && !cur.Deployed:
+		c.Diffs = append(c.Diffs, changed("deployed", "false", "true"))
+		id := cur.ID
+		c.apply = func() error {
+			return p.client.DeployDependencyByDependencyID(id)
+		}
+	}
+	p.add(c)
+}
+
+func added(path, value string) fieldDiff {
+	return fieldDiff{Op: "+", Path: path, New: value}
+}
+
+func removed(path, value string) fieldDiff {
+	return fieldDiff{Op: "-", Path: path, Old: value}
+}
+
+func changed(path, old, new string) fieldDiff {
+	return fieldDiff{Op: "~", Path: path, Old: old, New: new}
+}
+
+func diffString(path, old, new string) []fieldDiff {
+	if old == new {
+		return nil
+	}
+	return []fieldDiff{changed(path, quote(old), quote(new))}
+}
+
+func quote(s string) string {
+	return strconv.Quote(s)
+}
+
+func configFilePath(name string) string {
+	return "configFiles[" + quote(name) + "]"
+}
+
+// sparkArgsDiff compares spark arguments field by field, map entries such as confs key by key
+func sparkArgsDiff(old, new predixinsights.SparkArguments) []fieldDiff {
+	o, n := flattenSparkArgs(old), flattenSparkArgs(new)
+	keys := sortedKeys(o)
+	for k := range n {
+		if _, ok := o[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	diffs := []fieldDiff{}
+	for _, k := range keys {
+		ov, inOld := o[k]
+		nv, inNew := n[k]
+		switch {
+		case !inOld:
+			diffs = append(diffs, added(k, nv))
+		case !inNew:
+			diffs = append(diffs, removed(k, ov))
+		case ov != nv:
+			diffs = append(diffs, changed(k, ov, nv))
+		}
+	}
+	return diffs
+}
+
+func flattenSparkArgs(sa predixinsights.SparkArguments) map[string]string {
+	b, _ := json.Marshal(&sa)
+	fields := map[string]interface{}{}
+	json.Unmarshal(b, &fields)
+	flat := map[string]string{}
+	for k, v := range fields {
+		if m, ok := v.(map[string]interface{}); ok {
+			for mk, mv := range m {
+				flat["sparkArguments."+k+"["+quote(mk)+"]"] = jsonString(mv)
+			}
+			continue
+		}
+		flat["sparkArguments."+k] = jsonString(v)
+	}
+	return flat
+}
+
+func jsonString(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// tagsDiff compares two tag sets, ignoring order
+func tagsDiff(old, new []string) []fieldDiff {
+	diffs := []fieldDiff{}
+	for _, t := range new {
+		if !containsString(old, t) {
+			diffs = append(diffs, added("tags", quote(t)))
+		}
+	}
+	for _, t := range old {
+		if !containsString(new, t) {
+			diffs = append(diffs, removed("tags", quote(t)))
+		}
+	}
+	return diffs
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+)
+
+func TestTagsDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new []string
+		want     []fieldDiff
+	}{
+		{"both empty", nil, nil, []fieldDiff{}},
+		{"same tags in another order", []string{"a", "b"}, []string{"b", "a"}, []fieldDiff{}},
+		{"added", []string{"a"}, []string{"a", "b"}, []fieldDiff{{Op: "+", Path: "tags", New: `"b"`}}},
+		{"removed", []string{"a", "b"}, []string{"a"}, []fieldDiff{{Op: "-", Path: "tags", Old: `"b"`}}},
+		{"added before removed", []string{"a"}, []string{"b"}, []fieldDiff{{Op: "+", Path: "tags", New: `"b"`}, {Op: "-", Path: "tags", Old: `"a"`}}},
+	}
+	for _, test := range tests {
+		if got := tagsDiff(test.old, test.new); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSparkArgsDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new predixinsights.SparkArguments
+		want     []fieldDiff
+	}{
+		{"equal", predixinsights.SparkArguments{ClassName: "Main", NumExecutors: 2}, predixinsights.SparkArguments{ClassName: "Main", NumExecutors: 2}, []fieldDiff{}},
+		{"changed field", predixinsights.SparkArguments{NumExecutors: 2}, predixinsights.SparkArguments{NumExecutors: 4}, []fieldDiff{
+			{Op: "~", Path: "sparkArguments.numExecutors", Old: "2", New: "4"},
+		}},
+		{"added and removed fields", predixinsights.SparkArguments{ClassName: "Main"}, predixinsights.SparkArguments{DriverMemory: "2g"}, []fieldDiff{
+			{Op: "-", Path: "sparkArguments.className", Old: `"Main"`},
+			{Op: "+", Path: "sparkArguments.driverMemory", New: `"2g"`},
+		}},
+		{"map entries key by key", predixinsights.SparkArguments{Confs: map[string]string{"a": "1", "b": "2"}}, predixinsights.SparkArguments{Confs: map[string]string{"b": "3", "c": "4"}}, []fieldDiff{
+			{Op: "-", Path: `sparkArguments.confs["a"]`, Old: `"1"`},
+			{Op: "~", Path: `sparkArguments.confs["b"]`, Old: `"2"`, New: `"3"`},
+			{Op: "+", Path: `sparkArguments.confs["c"]`, New: `"4"`},
+		}},
+		{"lists as a whole", predixinsights.SparkArguments{ApplicationArgs: []string{"a"}}, predixinsights.SparkArguments{ApplicationArgs: []string{"a", "b"}}, []fieldDiff{
+			{Op: "~", Path: "sparkArguments.applicationArgs", Old: `["a"]`, New: `["a","b"]`},
+		}},
+	}
+	for _, test := range tests {
+		if got := sparkArgsDiff(test.old, test.new); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// newConfigServer serves the JSON config files of flow f1 by name and counts the downloads
+func newConfigServer(t *testing.T, files map[string]string, downloads *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Query().Get("file")]
+		if r.URL.Path != "/api/v1/flows/f1/config" || !ok {
+			t.Errorf("unexpected request %s", r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		*downloads++
+		w.Write([]byte(content))
+	}))
+}
+
+func TestConfigFileChanged(t *testing.T) {
+	downloads := 0
+	server := newConfigServer(t, map[string]string{"c.json": `{"a": 1, "b": {"c": true}}`}, &downloads)
+	defer server.Close()
+	client := &predixinsights.Client{APIHost: server.URL}
+	tests := []struct {
+		name      string
+		file      string
+		local     string
+		want      bool
+		downloads int
+	}{
+		{"same JSON formatted differently", "c.json", `{"b":{"c":true},"a":1}`, false, 1},
+		{"changed JSON value", "c.json", `{"a": 2, "b": {"c": true}}`, true, 1},
+		{"added JSON key", "c.json", `{"a": 1, "b": {"c": true}, "d": null}`, true, 1},
+		{"other file of the same size", "c.yaml", "a: 2\n", true, 0},
+		{"other file of another size", "c.yaml", "a: 12\n", true, 0},
+	}
+	for _, test := range tests {
+		downloads = 0
+		got, err := configFileChanged(client, "f1", test.file, []byte(test.local))
+		if err != nil {
+			t.Fatalf("%s: err=%s", test.name, err.Error())
+		}
+		if got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+		if downloads != test.downloads {
+			t.Errorf("%s: %d downloads, want %d", test.name, downloads, test.downloads)
+		}
+	}
+}
+
+func testFlow(id, template, name string, args predixinsights.SparkArguments, tags ...interface{}) predixinsights.Flow {
+	return predixinsights.Flow{ID: id, Name: name, SparkArgs: args, Tags: tags, FlowTemplate: predixinsights.FlowTemplate{ID: "t-" + template, Name: template}}
+}
+
+func testPlan(flows ...predixinsights.Flow) *applyPlan {
+	p := &applyPlan{templateIDs: map[string]string{"A": "t-A", "B": "t-B", "C": "t-C"}, flows: map[string]predixinsights.Flow{}}
+	for _, f := range flows {
+		p.flows[flowKey(f.FlowTemplate.Name, f.Name)] = f
+	}
+	return p
+}
+
+func TestPlanFlow(t *testing.T) {
+	args := predixinsights.SparkArguments{ClassName: "Main", NumExecutors: 2}
+	moreExecutors := predixinsights.SparkArguments{ClassName: "Main", NumExecutors: 4}
+	tests := []struct {
+		name   string
+		flows  []predixinsights.Flow
+		flow   manifestFlow
+		action string
+		diffs  []fieldDiff
+	}{
+		{"new flow", nil, manifestFlow{Name: "x", Template: "A", SparkArgs: &args, Tags: []string{"team"}}, actionCreate, []fieldDiff{
+			added("template", `"A"`),
+			added("sparkArguments.className", `"Main"`),
+			added("sparkArguments.numExecutors", "2"),
+			added("tags", `"team"`),
+		}},
+		{"up to date", []predixinsights.Flow{testFlow("f1", "A", "x", args, "team", "pi-ttl:2030-01-01T00:00:00Z")}, manifestFlow{Name: "x", Template: "A", SparkArgs: &args, Tags: []string{"team"}}, actionNoOp, nil},
+		{"spark arguments and tags left alone when omitted", []predixinsights.Flow{testFlow("f1", "A", "x", args, "team")}, manifestFlow{Name: "x", Template: "A"}, actionNoOp, nil},
+		{"changed spark arguments and tags", []predixinsights.Flow{testFlow("f1", "A", "x", args, "team")}, manifestFlow{Name: "x", Template: "A", SparkArgs: &moreExecutors, Tags: []string{"other"}}, actionUpdate, []fieldDiff{
+			changed("sparkArguments.numExecutors", "2", "4"),
+			added("tags", `"other"`),
+			removed("tags", `"team"`),
+		}},
+		{"same name under another template", []predixinsights.Flow{testFlow("f1", "B", "x", args)}, manifestFlow{Name: "x", Template: "A"}, actionCreate, []fieldDiff{
+			added("template", `"A"`),
+		}},
+	}
+	for _, test := range tests {
+		p := testPlan(test.flows...)
+		if err := p.planFlow(test.flow); err != nil {
+			t.Fatalf("%s: err=%s", test.name, err.Error())
+		}
+		if len(p.Changes) != 1 {
+			t.Fatalf("%s: %d changes, want 1", test.name, len(p.Changes))
+		}
+		c := p.Changes[0]
+		if c.Action != test.action || c.Kind != "flow" || c.Name != test.flow.Name {
+			t.Errorf("%s: got %s %s %s, want %s flow %s", test.name, c.Action, c.Kind, c.Name, test.action, test.flow.Name)
+		}
+		if len(c.Diffs) != len(test.diffs) || (len(c.Diffs) > 0 && !reflect.DeepEqual(c.Diffs, test.diffs)) {
+			t.Errorf("%s: got diffs %v, want %v", test.name, c.Diffs, test.diffs)
+		}
+		if (c.apply != nil) != (test.action != actionNoOp) {
+			t.Errorf("%s: apply set %v for %s", test.name, c.apply != nil, c.Action)
+		}
+	}
+}
+
+func TestPlanPrune(t *testing.T) {
+	tenant := []predixinsights.Flow{
+		testFlow("a-x", "A", "x", predixinsights.SparkArguments{}),
+		testFlow("a-y", "A", "y", predixinsights.SparkArguments{}),
+		testFlow("b-x", "B", "x", predixinsights.SparkArguments{}),
+		testFlow("c-y", "C", "y", predixinsights.SparkArguments{}),
+		testFlow("d-z", "", "z", predixinsights.SparkArguments{}),
+	}
+	tests := []struct {
+		name     string
+		manifest manifest
+		want     []string
+	}{
+		{"flows of unlisted templates are kept", manifest{Flows: []manifestFlow{{Name: "x", Template: "A"}}}, []string{}},
+		{"unlisted flows of a listed template", manifest{FlowTemplates: []manifestFlowTemplate{{Name: "A"}}, Flows: []manifestFlow{{Name: "x", Template: "A"}}}, []string{"A/y"}},
+		{"a flow listed under another template", manifest{FlowTemplates: []manifestFlowTemplate{{Name: "A"}, {Name: "B"}}, Flows: []manifestFlow{{Name: "x", Template: "B"}, {Name: "y", Template: "A"}}}, []string{"A/x"}},
+		{"same name under an unlisted template", manifest{FlowTemplates: []manifestFlowTemplate{{Name: "A"}}, Flows: []manifestFlow{{Name: "x", Template: "A"}, {Name: "y", Template: "C"}}}, []string{"A/y"}},
+	}
+	for _, test := range tests {
+		p := testPlan(tenant...)
+		p.planPrune(&test.manifest)
+		deleted := []string{}
+		for _, c := range p.Changes {
+			if c.Action != actionDelete || c.Kind != "flow" {
+				t.Errorf("%s: unexpected change %s %s %s", test.name, c.Action, c.Kind, c.Name)
+			}
+			template, err := strconv.Unquote(c.Diffs[0].Old)
+			if err != nil {
+				t.Fatalf("%s: template of %s err=%s", test.name, c.Name, err.Error())
+			}
+			deleted = append(deleted, flowKey(template, c.Name))
+		}
+		if !reflect.DeepEqual(deleted, test.want) {
+			t.Errorf("%s: deleted %v, want %v", test.name, deleted, test.want)
+		}
+	}
+}
+
+func TestPlanDAG(t *testing.T) {
+	tenant := newFakeTenant(t)
+	d := tenant.addDAG("D", "1.0", "dag", "SPARK_PYTHON", "dag.py")
+	d.owner, d.interval = "o", "@daily"
+	tenant.addDAG("unscheduled", "1.0", "dag", "SPARK_PYTHON", "dag.py")
+	server := httptest.NewServer(tenant)
+	defer server.Close()
+	current := []predixinsights.DAG{{Name: "D", Type: "SPARK_PYTHON"}, {Name: "unscheduled", Type: "SPARK_PYTHON"}}
+	tests := []struct {
+		name   string
+		dag    manifestDAG
+		action string
+		diffs  []fieldDiff
+	}{
+		{"up to date", manifestDAG{Name: "D", Version: "1.0", Type: "SPARK_PYTHON", Description: "dag", Template: manifestDAGTemplate{Owner: "o", Interval: "@daily"}}, actionNoOp, nil},
+		{"changed version and template", manifestDAG{Name: "D", Version: "1.1", Type: "SPARK_PYTHON", Description: "dag", Template: manifestDAGTemplate{Owner: "p", Interval: "@hourly"}}, actionUpdate, []fieldDiff{
+			changed("version", `"1.0"`, `"1.1"`),
+			changed("template.owner", `"o"`, `"p"`),
+			changed("template.interval", `"@daily"`, `"@hourly"`),
+		}},
+		{"template unknown without a status", manifestDAG{Name: "unscheduled", Version: "1.0", Type: "SPARK_PYTHON", Description: "dag", Template: manifestDAGTemplate{Owner: "o"}}, actionNoOp, nil},
+	}
+	for _, test := range tests {
+		p := &applyPlan{client: &predixinsights.Client{APIHost: server.URL}}
+		if err := p.planDAG(test.dag, current); err != nil {
+			t.Fatalf("%s: err=%s", test.name, err.Error())
+		}
+		if len(p.Changes) != 1 {
+			t.Fatalf("%s: %d changes, want 1", test.name, len(p.Changes))
+		}
+		c := p.Changes[0]
+		if c.Action != test.action {
+			t.Errorf("%s: got %s, want %s", test.name, c.Action, test.action)
+		}
+		if len(c.Diffs) != len(test.diffs) || (len(c.Diffs) > 0 && !reflect.DeepEqual(c.Diffs, test.diffs)) {
+			t.Errorf("%s: got diffs %v, want %v", test.name, c.Diffs, test.diffs)
+		}
+	}
+}
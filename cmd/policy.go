@@ -0,0 +1,362 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+	"github.com/spf13/viper"
+)
+
+// policyOverrideLog records every --override-policy in ~/.pi
+const policyOverrideLog = "policy-overrides.log"
+
+var (
+	memoryPattern    = regexp.MustCompile(`(?i)^([0-9]+)([kmgtp]b?)?$`)
+	classNamePattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*(\.[A-Za-z_$][A-Za-z0-9_$]*)*$`)
+	// memoryConfs are Spark properties holding a JVM memory string
+	memoryConfs = []string{"spark.driver.memory", "spark.driver.memoryOverhead", "spark.driver.maxResultSize", "spark.executor.memory", "spark.executor.memoryOverhead", "spark.executor.pyspark.memory", "spark.memory.offHeap.size", "spark.kryoserializer.buffer.max"}
+	// sparkConfs are well known Spark properties, a key close to one of them is likely a typo
+	sparkConfs = []string{
+		"spark.app.name", "spark.driver.cores", "spark.driver.memory", "spark.driver.memoryOverhead", "spark.driver.maxResultSize",
+		"spark.driver.extraClassPath", "spark.driver.extraJavaOptions", "spark.driver.extraLibraryPath", "spark.driver.userClassPathFirst",
+		"spark.executor.cores", "spark.executor.instances", "spark.executor.memory", "spark.executor.memoryOverhead", "spark.executor.pyspark.memory",
+		"spark.executor.extraClassPath", "spark.executor.extraJavaOptions", "spark.executor.extraLibraryPath", "spark.executor.userClassPathFirst",
+		"spark.executor.heartbeatInterval", "spark.files", "spark.jars", "spark.jars.packages", "spark.submit.pyFiles", "spark.local.dir",
+		"spark.logConf", "spark.master", "spark.submit.deployMode", "spark.task.cpus", "spark.task.maxFailures", "spark.default.parallelism",
+		"spark.serializer", "spark.kryoserializer.buffer", "spark.kryoserializer.buffer.max", "spark.kryo.registrationRequired", "spark.rdd.compress",
+		"spark.memory.fraction", "spark.memory.storageFraction", "spark.memory.offHeap.enabled", "spark.memory.offHeap.size",
+		"spark.shuffle.compress", "spark.shuffle.spill.compress", "spark.shuffle.service.enabled", "spark.shuffle.file.buffer",
+		"spark.reducer.maxSizeInFlight", "spark.io.compression.codec", "spark.broadcast.compress", "spark.network.timeout",
+		"spark.locality.wait", "spark.speculation", "spark.speculation.multiplier", "spark.speculation.quantile", "spark.scheduler.mode",
+		"spark.dynamicAllocation.enabled", "spark.dynamicAllocation.minExecutors", "spark.dynamicAllocation.maxExecutors",
+		"spark.dynamicAllocation.initialExecutors", "spark.dynamicAllocation.executorIdleTimeout",
+		"spark.eventLog.enabled", "spark.eventLog.dir", "spark.ui.enabled", "spark.ui.port", "spark.yarn.queue", "spark.yarn.maxAppAttempts",
+		"spark.yarn.am.memory", "spark.yarn.am.cores", "spark.yarn.tags", "spark.sql.shuffle.partitions", "spark.sql.autoBroadcastJoinThreshold",
+		"spark.sql.adaptive.enabled", "spark.sql.files.maxPartitionBytes", "spark.sql.session.timeZone", "spark.sql.warehouse.dir",
+		"spark.sql.sources.partitionOverwriteMode", "spark.sql.parquet.compression.codec", "spark.sql.broadcastTimeout",
+		"spark.streaming.backpressure.enabled", "spark.streaming.kafka.maxRatePerPartition", "spark.streaming.stopGracefullyOnShutdown",
+	}
+)
+
+// policy holds the team rules enforced on every create, update and launch, read from ~/.pi/policy.yaml or --policy
+type policy struct {
+	MaxExecutors      int      `json:"maxExecutors,omitempty"`
+	MaxExecutorMemory string   `json:"maxExecutorMemory,omitempty"`
+	MaxDriverMemory   string   `json:"maxDriverMemory,omitempty"`
+	MaxDriverCores    int      `json:"maxDriverCores,omitempty"`
+	ForbiddenConfs    []string `json:"forbiddenConfs,omitempty"`
+	RequiredTags      []string `json:"requiredTags,omitempty"`
+}
+
+// policyOverride is one line of the override log
+type policyOverride struct {
+	Time       string   `json:"time"`
+	User       string   `json:"user"`
+	ClientID   string   `json:"clientID"`
+	TenantID   string   `json:"tenantID"`
+	Command    string   `json:"command"`
+	Resource   string   `json:"resource"`
+	Violations []string `json:"violations"`
+}
+
+// loadPolicy reads the policy file, a missing default file is an empty policy
+func loadPolicy() (*policy, error) {
+	p := &policy{}
+	file := viper.GetString("policy")
+	if file == "" {
+		file = filepath.Join(dir, "policy.yaml")
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			return p, nil
+		}
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading policy err=%s", err.Error())
+	}
+	if err := decodeDocument(b, p); err != nil {
+		return nil, fmt.Errorf("invalid policy %s err=%s", file, err.Error())
+	}
+	for _, m := range []string{p.MaxExecutorMemory, p.MaxDriverMemory} {
+		if _, err := memoryMiB(m); m != "" && err != nil {
+			return nil, fmt.Errorf("invalid policy %s err=%s", file, err.Error())
+		}
+	}
+	for _, pattern := range p.ForbiddenConfs {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid policy %s forbiddenConfs %q err=%s", file, pattern, err.Error())
+		}
+	}
+	return p, nil
+}
+
+// enforce checks args, when not nil, with the validator and the policy and tags, when not nil, against the required
+// tags of the policy. Violations fail unless --override-policy is given, every override is logged.
+func enforce(resource, flowType string, args *predixinsights.SparkArguments, tags []string) error {
+	p, err := loadPolicy()
+	if err != nil {
+		return err
+	}
+	violations := []string{}
+	if args != nil {
+		violations = append(violations, validateSparkArgs(*args, flowType)...)
+		violations = append(violations, p.checkSparkArgs(*args)...)
+	}
+	if tags != nil {
+		violations = append(violations, p.checkTags(tags)...)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	if !viper.GetBool("override-policy") {
+		return fmt.Errorf("%s violates the policy, fix it or give --override-policy, which is logged:\n  %s", resource, strings.Join(violations, "\n  "))
+	}
+	logged, err := logPolicyOverride(resource, violations)
+	if err != nil {
+		return fmt.Errorf("override of the policy could not be logged err=%s", err.Error())
+	}
+	fmt.Fprintf(os.Stderr, "warning: policy overridden for %s, logged to %s:\n  %s\n", resource, logged, strings.Join(violations, "\n  "))
+	return nil
+}
+
+// enforcePolicy enforces the policy on the spark arguments and tags given in m, see enforce
+func (m *manifest) enforcePolicy() error {
+	types := map[string]string{}
+	failed := []string{}
+	for _, ft := range m.FlowTemplates {
+		types[ft.Name] = ft.Type
+		if err := enforce("flow template "+ft.Name, ft.Type, ft.SparkArgs, ft.Tags); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	for _, f := range m.Flows {
+		if err := enforce("flow "+f.Name, types[f.Template], f.SparkArgs, f.Tags); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "\n"))
+	}
+	return nil
+}
+
+// validateSparkArgs reports what Spark would reject or silently ignore
+func validateSparkArgs(args predixinsights.SparkArguments, flowType string) []string {
+	problems := []string{}
+	for _, m := range []struct{ name, value string }{{"driverMemory", args.DriverMemory}, {"executorMemory", args.ExecutorMemory}} {
+		if _, err := memoryMiB(m.value); m.value != "" && err != nil {
+			problems = append(problems, m.name+": "+err.Error())
+		}
+	}
+	if args.NumExecutors < 0 {
+		problems = append(problems, "numExecutors: must not be negative")
+	}
+	if args.DriverCores < 0 {
+		problems = append(problems, "driverCores: must not be negative")
+	}
+	for _, key := range sortedKeys(args.Confs) {
+		switch {
+		case !strings.HasPrefix(key, "spark."):
+			problems = append(problems, fmt.Sprintf("confs[%s]: Spark ignores properties not starting with spark.", key))
+		case containsString(memoryConfs, key):
+			if _, err := memoryMiB(args.Confs[key]); err != nil {
+				problems = append(problems, fmt.Sprintf("confs[%s]: %s", key, err.Error()))
+			}
+		case !containsString(sparkConfs, key):
+			if s := suggest(key, sparkConfs); s != "" {
+				problems = append(problems, fmt.Sprintf("confs[%s]: unknown Spark property, did you mean %s?", key, s))
+			}
+		}
+	}
+	switch flowType {
+	case "SPARK_JAVA":
+		if args.ClassName != "" && !classNamePattern.MatchString(args.ClassName) {
+			problems = append(problems, fmt.Sprintf("className: %q is not a Java class name", args.ClassName))
+		}
+		if args.FileName != "" && !strings.HasSuffix(args.FileName, ".jar") {
+			problems = append(problems, fmt.Sprintf("fileName: %s is not a jar, flow type is SPARK_JAVA", args.FileName))
+		}
+	case "SPARK_PYTHON":
+		if args.ClassName != "" {
+			problems = append(problems, "className: must be empty, flow type is SPARK_PYTHON")
+		}
+		if args.FileName != "" && !strings.HasSuffix(args.FileName, ".py") {
+			problems = append(problems, fmt.Sprintf("fileName: %s is not a Python file, flow type is SPARK_PYTHON", args.FileName))
+		}
+	}
+	return problems
+}
+
+// checkSparkArgs applies the policy to args, including the Spark properties that set the same values
+func (p *policy) checkSparkArgs(args predixinsights.SparkArguments) []string {
+	problems := []string{}
+	if p.MaxExecutors > 0 {
+		if args.NumExecutors > p.MaxExecutors {
+			problems = append(problems, fmt.Sprintf("numExecutors: %d exceeds the maximum of %d", args.NumExecutors, p.MaxExecutors))
+		}
+		for _, key := range []string{"spark.executor.instances", "spark.dynamicAllocation.maxExecutors"} {
+			if n, err := strconv.Atoi(args.Confs[key]); err == nil && n > p.MaxExecutors {
+				problems = append(problems, fmt.Sprintf("confs[%s]: %d exceeds the maximum of %d executors", key, n, p.MaxExecutors))
+			}
+		}
+	}
+	if p.MaxDriverCores > 0 && args.DriverCores > p.MaxDriverCores {
+		problems = append(problems, fmt.Sprintf("driverCores: %d exceeds the maximum of %d", args.DriverCores, p.MaxDriverCores))
+	}
+	limits := []struct{ name, value, max string }{
+		{"executorMemory", args.ExecutorMemory, p.MaxExecutorMemory},
+		{"confs[spark.executor.memory]", args.Confs["spark.executor.memory"], p.MaxExecutorMemory},
+		{"driverMemory", args.DriverMemory, p.MaxDriverMemory},
+		{"confs[spark.driver.memory]", args.Confs["spark.driver.memory"], p.MaxDriverMemory},
+	}
+	for _, l := range limits {
+		if l.max == "" || l.value == "" {
+			continue
+		}
+		value, err := memoryMiB(l.value)
+		max, _ := memoryMiB(l.max)
+		if err == nil && value > max {
+			problems = append(problems, fmt.Sprintf("%s: %s exceeds the maximum of %s", l.name, l.value, l.max))
+		}
+	}
+	for _, key := range sortedKeys(args.Confs) {
+		for _, pattern := range p.ForbiddenConfs {
+			if ok, _ := path.Match(pattern, key); ok {
+				problems = append(problems, fmt.Sprintf("confs[%s]: forbidden by the policy", key))
+				break
+			}
+		}
+	}
+	return problems
+}
+
+// checkTags reports the required tags missing from tags, a required tag is matched by itself or by a tag
+// starting with it and a colon, e.g. team matches team:data
+func (p *policy) checkTags(tags []string) []string {
+	problems := []string{}
+	for _, required := range p.RequiredTags {
+		found := false
+		for _, t := range tags {
+			if t == required || strings.HasPrefix(t, required+":") {
+				found = true
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("tags: %s is required by the policy", required))
+		}
+	}
+	return problems
+}
+
+// memoryMiB parses a JVM memory string like 512m or 4g, a plain number is in MiB like Spark reads it
+func memoryMiB(s string) (int64, error) {
+	m := memoryPattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("%q is not a memory size like 512m or 4g", s)
+	}
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	switch strings.TrimSuffix(strings.ToLower(m[2]), "b") {
+	case "k":
+		return n / 1024, nil
+	case "g":
+		return n * 1024, nil
+	case "t":
+		return n * 1024 * 1024, nil
+	case "p":
+		return n * 1024 * 1024 * 1024, nil
+	}
+	return n, nil
+}
+
+// suggest returns the candidate within two edits of s, if any
+func suggest(s string, candidates []string) string {
+	best, bestDistance := "", 3
+	for _, c := range candidates {
+		if d := editDistance(s, c); d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance of a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// logPolicyOverride appends the override to the log, returning its path
+func logPolicyOverride(resource string, violations []string) (string, error) {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	b, err := json.Marshal(policyOverride{
+		Time:       time.Now().UTC().Format(time.RFC3339),
+		User:       name,
+		ClientID:   viper.GetString("ClientID"),
+		TenantID:   viper.GetString("TenantID"),
+		Command:    commandLine(),
+		Resource:   resource,
+		Violations: violations,
+	})
+	if err != nil {
+		return "", err
+	}
+	logPath := filepath.Join(dir, policyOverrideLog)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(logPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return "", err
+	}
+	return logPath, f.Close()
+}
+
+// commandLine returns the command without its flags, which may hold secrets
+func commandLine() string {
+	c, _, err := RootCmd.Find(os.Args[1:])
+	if err != nil {
+		return RootCmd.Name()
+	}
+	return c.CommandPath()
+}
@@ -0,0 +1,330 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+	"github.com/spf13/cobra"
+)
+
+// presetDir holds presets, in ~/.pi for the user and in the nearest .pi of the working directory for the project
+const presetDir = "presets"
+
+var presetCmd = &cobra.Command{
+	Use:   "preset",
+	Short: "Spark Argument Presets",
+	Long: `Named partial spark arguments, such as small, large-shuffle or gc-tuned, applied with --preset by pi flow create and update-spark-args.
+Presets are read from ~/.pi/presets/NAME.yaml and from .pi/presets/NAME.yaml in the working directory or its nearest parent, the project preset is layered on the user preset of the same name.
+A preset is a JSON merge patch of the spark arguments: maps like confs merge key by key, other values and lists replace and null removes a value. Presets listed in extends are applied first.`,
+}
+
+func init() {
+	RootCmd.AddCommand(presetCmd)
+}
+
+var listPresetsCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List Presets",
+	Long:    `List the user and project presets.`,
+	Example: "  pi preset list",
+	Run: func(cmd *cobra.Command, args []string) {
+		presets, err := loadPresets()
+		if err != nil {
+			fmt.Println("error loading presets err=" + err.Error())
+			return
+		}
+		if len(presets) == 0 {
+			fmt.Println("No presets found in " + strings.Join(presetDirs(), " or "))
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSOURCE\tEXTENDS\tDESCRIPTION")
+		for _, name := range sortedKeys(presets) {
+			sources, extends, description := []string{}, []string{}, ""
+			for _, l := range presets[name] {
+				sources = append(sources, l.source)
+				extends = append(extends, l.Extends...)
+				description = orDefault(l.Description, description)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, strings.Join(sources, "+"), strings.Join(extends, ","), description)
+		}
+		w.Flush()
+	},
+}
+
+var showPresetCmd = &cobra.Command{
+	Use:     "show",
+	Short:   "Show a Preset",
+	Long:    `Print the spark arguments patch of a preset with its extends and project layer resolved.`,
+	Example: "  pi preset show --preset large-shuffle",
+	Run: func(cmd *cobra.Command, args []string) {
+		err := getMissingRequiredParams(showPresetPI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			return
+		}
+		presets, err := loadPresets()
+		if err != nil {
+			fmt.Println("error loading presets err=" + err.Error())
+			return
+		}
+		patches, err := resolvePreset(presets, showPresetPI.V.GetString("preset"), nil)
+		if err != nil {
+			fmt.Println("error resolving preset err=" + err.Error())
+			return
+		}
+		var patch interface{} = map[string]interface{}{}
+		for _, p := range patches {
+			patch = composePatch(patch, p)
+		}
+		b, err := json.Marshal(map[string]interface{}{"sparkArguments": patch})
+		if err != nil {
+			fmt.Println("error printing preset err=" + err.Error())
+			return
+		}
+		prettyprint(b)
+	},
+}
+
+var diffPresetCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show the Changes of a Preset",
+	Long: `Print the changes applying the presets in order would make to the spark arguments of a flow, a flow template or another preset.
+The base is --against when given, else --flowTemplateID when given, else the flow of --flowID.`,
+	Example: "  pi preset diff --preset large-shuffle --flowID MY_FLOW_ID\n  pi preset diff --preset gc-tuned --against small",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(presetNames) == 0 {
+			fmt.Println("failed to get required parameters err=give --preset")
+			return
+		}
+		var base predixinsights.SparkArguments
+		desc := ""
+		switch {
+		case diffPresetPI.V.GetString("against") != "":
+			against := diffPresetPI.V.GetString("against")
+			if err := applyPresets(&base, []string{against}); err != nil {
+				fmt.Println("error applying preset err=" + err.Error())
+				return
+			}
+			desc = "preset " + against
+		case cmd.Flags().Changed("flowTemplateID") || diffPresetPI.V.GetString("flowID") == "" && diffPresetPI.V.GetString("flowTemplateID") != "":
+			client, err := login()
+			if err != nil {
+				fmt.Println("authentication error err=" + err.Error())
+				return
+			}
+			template, err := client.GetFlowTemplate(diffPresetPI.V.GetString("flowTemplateID"))
+			if err != nil {
+				fmt.Println("error getting flow template err=" + err.Error())
+				return
+			}
+			base, desc = template.SparkArgs, fmt.Sprintf("flow template %s (%s)", template.Name, template.ID)
+		case diffPresetPI.V.GetString("flowID") != "":
+			client, err := login()
+			if err != nil {
+				fmt.Println("authentication error err=" + err.Error())
+				return
+			}
+			flow, err := findFlow(client, diffPresetPI.V.GetString("flowID"))
+			if err != nil {
+				fmt.Println("error getting flow err=" + err.Error())
+				return
+			}
+			base, desc = flow.SparkArgs, fmt.Sprintf("flow %s (%s)", flow.Name, flow.ID)
+		default:
+			fmt.Println("failed to get required parameters err=give --against, --flowID or --flowTemplateID")
+			return
+		}
+		updated, err := copySparkArgs(base)
+		if err != nil {
+			fmt.Println("error applying preset err=" + err.Error())
+			return
+		}
+		if err := applyPresets(&updated, presetNames); err != nil {
+			fmt.Println("error applying preset err=" + err.Error())
+			return
+		}
+		diffs := sparkArgsDiff(base, updated)
+		if len(diffs) == 0 {
+			fmt.Printf("No changes, %s already matches preset %s.\n", desc, strings.Join(presetNames, ", "))
+			return
+		}
+		fmt.Printf("~ %s with preset %s\n", desc, strings.Join(presetNames, ", "))
+		for _, d := range diffs {
+			fmt.Println("    " + d.String())
+		}
+		cleanup(diffPresetPI)
+	},
+}
+
+// preset is read from a preset file
+type preset struct {
+	Description string                 `json:"description,omitempty"`
+	Extends     []string               `json:"extends,omitempty"`
+	SparkArgs   map[string]interface{} `json:"sparkArguments"`
+
+	source, path string
+}
+
+// presetDirs returns the user preset directory and, when there is one, the project preset directory
+func presetDirs() []string {
+	dirs := []string{filepath.Join(dir, presetDir)}
+	if d := projectPath(presetDir); d != "" && d != dirs[0] {
+		dirs = append(dirs, d)
+	}
+	return dirs
+}
+
+// projectPath returns .pi/name in the working directory or its nearest parent that has one, empty when none has
+func projectPath(name string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		p := filepath.Join(wd, ".pi", name)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+		parent := filepath.Dir(wd)
+		if parent == wd {
+			return ""
+		}
+		wd = parent
+	}
+}
+
+// loadPresets reads every preset file, the user layer of a name first. Missing directories hold no presets.
+func loadPresets() (map[string][]preset, error) {
+	presets := map[string][]preset{}
+	for i, d := range presetDirs() {
+		source := "user"
+		if i > 0 {
+			source = "project"
+		}
+		files, err := ioutil.ReadDir(d)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			ext := filepath.Ext(f.Name())
+			if f.IsDir() || ext != ".yaml" && ext != ".yml" && ext != ".json" {
+				continue
+			}
+			p, err := loadPreset(filepath.Join(d, f.Name()))
+			if err != nil {
+				return nil, err
+			}
+			p.source = source
+			name := strings.TrimSuffix(f.Name(), ext)
+			presets[name] = append(presets[name], p)
+		}
+	}
+	return presets, nil
+}
+
+func loadPreset(path string) (preset, error) {
+	p := preset{path: path}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return p, err
+	}
+	if err := decodeDocument(b, &p); err != nil {
+		return p, fmt.Errorf("invalid preset %s err=%s", path, err.Error())
+	}
+	if f := unknownField(p.SparkArgs, reflect.TypeOf(predixinsights.SparkArguments{}), "sparkArguments"); f != "" {
+		return p, fmt.Errorf("invalid preset %s err=unknown field at %s", path, f)
+	}
+	if err := patchSparkArgs(&predixinsights.SparkArguments{}, p.SparkArgs); err != nil {
+		return p, fmt.Errorf("invalid preset %s err=%s", path, err.Error())
+	}
+	return p, nil
+}
+
+// resolvePreset returns the patches of name in the order they apply: for every layer of name the presets it extends,
+// then the layer itself. seen holds the presets being resolved, to report cycles.
+func resolvePreset(presets map[string][]preset, name string, seen []string) ([]map[string]interface{}, error) {
+	if containsString(seen, name) {
+		return nil, fmt.Errorf("preset %s extends itself through %s", name, strings.Join(append(seen, name), " -> "))
+	}
+	layers, ok := presets[name]
+	if !ok {
+		names := sortedKeys(presets)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("unknown preset %s, no presets found in %s", name, strings.Join(presetDirs(), " or "))
+		}
+		if s := suggest(name, names); s != "" {
+			return nil, fmt.Errorf("unknown preset %s, did you mean %s?", name, s)
+		}
+		return nil, fmt.Errorf("unknown preset %s, expected one of %s", name, strings.Join(names, ", "))
+	}
+	patches := []map[string]interface{}{}
+	for _, l := range layers {
+		for _, e := range l.Extends {
+			extended, err := resolvePreset(presets, e, append(seen, name))
+			if err != nil {
+				return nil, err
+			}
+			patches = append(patches, extended...)
+		}
+		patches = append(patches, l.SparkArgs)
+	}
+	return patches, nil
+}
+
+// applyPresets patches args with the presets in order, a later preset wins over an earlier one
+func applyPresets(args *predixinsights.SparkArguments, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	presets, err := loadPresets()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		patches, err := resolvePreset(presets, name, nil)
+		if err != nil {
+			return err
+		}
+		for _, p := range patches {
+			if err := patchSparkArgs(args, p); err != nil {
+				return fmt.Errorf("preset %s: %s", name, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// composePatch combines two merge patches into one, unlike mergePatch it keeps the nulls removing values
+func composePatch(first, second interface{}) interface{} {
+	s, ok := second.(map[string]interface{})
+	if !ok {
+		return second
+	}
+	f, ok := first.(map[string]interface{})
+	if !ok {
+		f = map[string]interface{}{}
+	}
+	composed := map[string]interface{}{}
+	for k, v := range f {
+		composed[k] = v
+	}
+	for k, v := range s {
+		if v == nil {
+			composed[k] = nil
+			continue
+		}
+		composed[k] = composePatch(composed[k], v)
+	}
+	return composed
+}
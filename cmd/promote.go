@@ -0,0 +1,407 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// promotedTagPrefix tags promoted flow templates and flows with <environment>@<source version>
+const promotedTagPrefix = "pi-promoted:"
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote",
+	Short: "Promote Flows Between Environments",
+	Long: `Copy the selected flow templates and flows, with their spark arguments, tags and config files, from one environment to another.
+An environment is a config file, --from dev reads ~/.pi/dev.json unless dev is the path of a file. Create one with pi --config ~/.pi/dev.json configure -i.
+The --overlay file patches spark arguments, tags and config files for the target environment. The changes are printed as a plan and applied after confirmation,
+promoted resources are tagged pi-promoted:<from>@<source version>. The API does not return artifacts, flow templates missing in the target are created from --artifactDir.`,
+	Example: "  pi promote --from dev --to prod --name MY_FLOW_NAME --overlay overlays/prod.yaml --plan\n  pi promote --from dev --to prod --tag release --overlay overlays/prod.yaml --artifactDir build/",
+	Run: func(cmd *cobra.Command, args []string) {
+		err := getMissingRequiredParams(promotePI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			os.Exit(exitError)
+		}
+		from, to := promotePI.V.GetString("from"), promotePI.V.GetString("to")
+		sel := exportSelector{
+			names: splitList(promotePI.V.GetString("name")),
+			ids:   splitList(promotePI.V.GetString("id")),
+			tags:  splitList(promotePI.V.GetString("tag")),
+		}
+		if len(sel.names) == 0 && len(sel.ids) == 0 && len(sel.tags) == 0 {
+			fmt.Println("select the flow templates and flows to promote with --name, --id or --tag")
+			os.Exit(exitError)
+		}
+		source, err := connectEnvironment(from)
+		if err != nil {
+			fmt.Println("authentication error environment=" + from + " err=" + err.Error())
+			os.Exit(exitError)
+		}
+		target, err := connectEnvironment(to)
+		if err != nil {
+			fmt.Println("authentication error environment=" + to + " err=" + err.Error())
+			os.Exit(exitError)
+		}
+		dir, err := ioutil.TempDir("", "pi-promote")
+		if err != nil {
+			fmt.Println("error creating temporary directory err=" + err.Error())
+			os.Exit(exitError)
+		}
+		defer os.RemoveAll(dir)
+		code := promote(source, target, sel, from, to, dir)
+		if code != 0 {
+			os.RemoveAll(dir)
+			os.Exit(code)
+		}
+	},
+}
+
+// promote plans and applies the promotion, returning the exit code
+func promote(source, target *predixinsights.Client, sel exportSelector, from, to, dir string) int {
+	m, versions, err := promotionManifest(source, target, sel, from, dir, promotePI.V.GetString("artifactDir"))
+	if err != nil {
+		fmt.Println("error reading " + from + " err=" + err.Error())
+		return exitError
+	}
+	if path := promotePI.V.GetString("overlay"); path != "" {
+		o, err := loadOverlay(path)
+		if err != nil {
+			fmt.Println("error loading overlay err=" + err.Error())
+			return exitError
+		}
+		if err := o.apply(m); err != nil {
+			fmt.Println("error applying overlay err=" + err.Error())
+			return exitError
+		}
+	}
+	plan, err := newApplyPlan(target, m, false)
+	if err != nil {
+		fmt.Println("error planning promotion err=" + err.Error())
+		return exitError
+	}
+	fmt.Printf("Promoting %d flow template(s) and %d flow(s) from %s to %s\n\n", len(m.FlowTemplates), len(m.Flows), from, to)
+	plan.print(os.Stdout)
+	if promotePI.V.GetBool("plan") {
+		if plan.pending() > 0 {
+			return exitChanges
+		}
+		return 0
+	}
+	if plan.pending() == 0 {
+		fmt.Printf("No changes. %s matches %s.\n", to, from)
+		return 0
+	}
+	if !promotePI.V.GetBool("force") && !viper.GetBool("dry-run") {
+		fmt.Printf("\nPromote these changes to %s? ", to)
+		if !askForConfirmation() {
+			return 0
+		}
+	}
+	fmt.Println()
+	if err := plan.execute(os.Stdout); err != nil {
+		fmt.Println("error promoting err=" + err.Error())
+		return exitError
+	}
+	if err := recordPromotion(target, m, versions, from); err != nil {
+		fmt.Println("error tagging promoted resources err=" + err.Error())
+		return exitError
+	}
+	fmt.Printf("Promote complete. %d resource(s) changed.\n", plan.pending())
+	return 0
+}
+
+// connectEnvironment logs in with the config file of an environment
+func connectEnvironment(env string) (*predixinsights.Client, error) {
+	path := env
+	if _, err := os.Stat(path); err != nil {
+		path = filepath.Join(dir, env+".json")
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"APIHost", "TenantID", "IssuerID", "ClientID", "ClientSecret"} {
+		if v.GetString(key) == "" {
+			return nil, fmt.Errorf("%s is missing in %s", key, path)
+		}
+	}
+	return connect(v)
+}
+
+// promotionManifest exports the selected flow templates and flows of source to dir, it returns the manifest
+// and the source version of every resource keyed by kind/name, flows by flow/<template>/<name>
+func promotionManifest(source, target *predixinsights.Client, sel exportSelector, from, dir, artifactDir string) (*manifest, map[string]string, error) {
+	exported, _, err := exportManifest(source, sel, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(exported.FlowTemplates) == 0 && len(exported.Flows) == 0 {
+		return nil, nil, errors.New("no flow templates or flows match the selection")
+	}
+	for _, ft := range exported.FlowTemplates {
+		if artifactDir != "" && ft.File != "" {
+			// a missing artifact is reported below, when the target needs it
+			copyFile(filepath.Join(artifactDir, ft.File), filepath.Join(dir, ft.File))
+		}
+	}
+	m, err := readManifests(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	// DAGs and dependencies matching the selection are not promoted
+	m.DAGs, m.Dependencies = nil, nil
+
+	versions := map[string]string{}
+	templates := []manifestFlowTemplate{}
+	for _, ft := range m.FlowTemplates {
+		versions["flow-template/"+ft.Name] = ft.Version
+		if _, err := os.Stat(ft.File); err == nil {
+			templates = append(templates, ft)
+			continue
+		}
+		res, err := target.GetFlowTemplateByName(ft.Name)
+		if err != nil || !containsTemplate(res.Content, ft.Name) {
+			return nil, nil, fmt.Errorf("flow template '%s' does not exist in the target and its artifact %s is not in --artifactDir", ft.Name, filepath.Base(ft.File))
+		}
+		fmt.Fprintf(os.Stderr, "warning: artifact of flow template %s is not in --artifactDir, the template in the target is left unchanged\n", ft.Name)
+	}
+	m.FlowTemplates = templates
+
+	flows, err := source.GetAllFlows(maxFlowPages)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, f := range flows {
+		version := f.Version
+		if version == "" {
+			version = f.FlowTemplate.Version
+		}
+		versions["flow/"+flowKey(f.FlowTemplate.Name, f.Name)] = version
+	}
+	return m, versions, nil
+}
+
+// recordPromotion replaces the promoted tag of every promoted resource in the target
+func recordPromotion(target *predixinsights.Client, m *manifest, versions map[string]string, from string) error {
+	tag := func(kind, name string) string {
+		return promotedTagPrefix + from + "@" + versions[kind+"/"+name]
+	}
+	for _, mt := range m.FlowTemplates {
+		res, err := target.GetFlowTemplateByName(mt.Name)
+		if err != nil {
+			return err
+		}
+		for _, ft := range res.Content {
+			if ft.Name != mt.Name {
+				continue
+			}
+			if _, err := target.SaveTagsForFlowTemplate(ft.ID, promotedTags(ft.Tags, tag("flow-template", mt.Name))); err != nil {
+				return err
+			}
+		}
+	}
+	if len(m.Flows) == 0 {
+		return nil
+	}
+	flows, err := target.GetAllFlows(maxFlowPages)
+	if err != nil {
+		return err
+	}
+	for _, mf := range m.Flows {
+		for _, f := range flows {
+			if f.Name != mf.Name || f.FlowTemplate.Name != mf.Template {
+				continue
+			}
+			if _, err := target.SaveTagsForFlow(f.FlowTemplate.ID, f.ID, promotedTags(flowTags(f.Tags), tag("flow", flowKey(mf.Template, mf.Name)))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func promotedTags(current []string, promoted string) predixinsights.TagsArray {
+	tags := predixinsights.TagsArray{}
+	for _, t := range current {
+		if !strings.HasPrefix(t, promotedTagPrefix) {
+			tags = append(tags, t)
+		}
+	}
+	return append(tags, promoted)
+}
+
+// overlay patches the promoted resources for the target environment
+type overlay struct {
+	FlowTemplates map[string]overlayFlowTemplate `json:"flowTemplates,omitempty"`
+	Flows         map[string]overlayFlow         `json:"flows,omitempty"`
+}
+
+// overlayFlowTemplate patches spark arguments as a JSON merge patch (RFC 7386), null removes a value
+type overlayFlowTemplate struct {
+	SparkArgs  map[string]interface{} `json:"sparkArguments,omitempty"`
+	AddTags    []string               `json:"addTags,omitempty"`
+	RemoveTags []string               `json:"removeTags,omitempty"`
+}
+
+// overlayFlow also patches the content of JSON config files by file name
+type overlayFlow struct {
+	SparkArgs   map[string]interface{}            `json:"sparkArguments,omitempty"`
+	AddTags     []string                          `json:"addTags,omitempty"`
+	RemoveTags  []string                          `json:"removeTags,omitempty"`
+	ConfigFiles map[string]map[string]interface{} `json:"configFiles,omitempty"`
+}
+
+func loadOverlay(path string) (*overlay, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	o := &overlay{}
+	if err := decodeStrict(b, o); err != nil {
+		return nil, fmt.Errorf("invalid overlay %s err=%s", path, err.Error())
+	}
+	return o, nil
+}
+
+// apply patches m, failing on overlays of resources that are not promoted
+func (o *overlay) apply(m *manifest) error {
+	for name := range o.FlowTemplates {
+		found := false
+		for i := range m.FlowTemplates {
+			if m.FlowTemplates[i].Name != name {
+				continue
+			}
+			found = true
+			ot, mt := o.FlowTemplates[name], &m.FlowTemplates[i]
+			if err := patchSparkArgs(mt.SparkArgs, ot.SparkArgs); err != nil {
+				return fmt.Errorf("flow template '%s' err=%s", name, err.Error())
+			}
+			mt.Tags = patchTags(mt.Tags, ot.AddTags, ot.RemoveTags)
+		}
+		if !found {
+			return fmt.Errorf("flow template '%s' is not promoted", name)
+		}
+	}
+	for name := range o.Flows {
+		found := false
+		for i := range m.Flows {
+			if m.Flows[i].Name != name {
+				continue
+			}
+			found = true
+			of, mf := o.Flows[name], &m.Flows[i]
+			if err := patchSparkArgs(mf.SparkArgs, of.SparkArgs); err != nil {
+				return fmt.Errorf("flow '%s' err=%s", name, err.Error())
+			}
+			mf.Tags = patchTags(mf.Tags, of.AddTags, of.RemoveTags)
+			for _, file := range sortedKeys(of.ConfigFiles) {
+				if err := patchConfigFile(mf, file, of.ConfigFiles[file]); err != nil {
+					return fmt.Errorf("flow '%s' err=%s", name, err.Error())
+				}
+			}
+		}
+		if !found {
+			return fmt.Errorf("flow '%s' is not promoted", name)
+		}
+	}
+	return nil
+}
+
+// patchSparkArgs merges patch into args, rejecting fields SparkArguments does not have
+func patchSparkArgs(args *predixinsights.SparkArguments, patch map[string]interface{}) error {
+	if args == nil || patch == nil {
+		return nil
+	}
+	b, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	var current interface{}
+	if err := json.Unmarshal(b, &current); err != nil {
+		return err
+	}
+	b, err = json.Marshal(mergePatch(current, patch))
+	if err != nil {
+		return err
+	}
+	patched := predixinsights.SparkArguments{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&patched); err != nil {
+		return fmt.Errorf("invalid sparkArguments patch err=%s", err.Error())
+	}
+	*args = patched
+	return nil
+}
+
+func patchConfigFile(mf *manifestFlow, name string, patch map[string]interface{}) error {
+	for _, cf := range mf.ConfigFiles {
+		if cf.Name != name {
+			continue
+		}
+		b, err := ioutil.ReadFile(cf.File)
+		if err != nil {
+			return err
+		}
+		var content interface{}
+		if err := json.Unmarshal(b, &content); err != nil {
+			return fmt.Errorf("config file %s is not JSON err=%s", name, err.Error())
+		}
+		b, err = json.MarshalIndent(mergePatch(content, patch), "", "  ")
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(cf.File, append(b, '\n'), os.FileMode(0644))
+	}
+	return fmt.Errorf("config file %s is not promoted", name)
+}
+
+// patchTags applies the addTags and removeTags of an overlay, a resource without tags starts from none
+func patchTags(tags, add, remove []string) []string {
+	if len(add) == 0 && len(remove) == 0 {
+		return tags
+	}
+	patched := []string{}
+	for _, t := range tags {
+		if !containsString(remove, t) {
+			patched = append(patched, t)
+		}
+	}
+	for _, t := range add {
+		if !containsString(patched, t) {
+			patched = append(patched, t)
+		}
+	}
+	return patched
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386) to target
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatch(t[k], v)
+	}
+	return t
+}
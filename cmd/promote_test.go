@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPatchTags(t *testing.T) {
+	tests := []struct {
+		name              string
+		tags, add, remove []string
+		want              []string
+	}{
+		{"no overlay keeps nil", nil, nil, nil, nil},
+		{"no overlay keeps tags", []string{"a"}, nil, nil, []string{"a"}},
+		{"added to a resource without tags", nil, []string{"prod"}, nil, []string{"prod"}},
+		{"removed from a resource without tags", nil, nil, []string{"dev"}, []string{}},
+		{"added and removed", []string{"a", "dev"}, []string{"prod", "a"}, []string{"dev"}, []string{"a", "prod"}},
+	}
+	for _, test := range tests {
+		if got := patchTags(test.tags, test.add, test.remove); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %#v, want %#v", test.name, got, test.want)
+		}
+	}
+}
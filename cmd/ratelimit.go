@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// maxThrottledRetries is the number of times a request answered with 429 is retried
+	maxThrottledRetries = 5
+	// minBackoff is the first wait after a 429 without a Retry-After header
+	minBackoff = 500 * time.Millisecond
+	// maxBackoff caps the wait between retries of a throttled request
+	maxBackoff = 30 * time.Second
+	// minRateFraction is the lowest fraction of maxRPS adaptive backoff will slow down to
+	minRateFraction = 0.1
+	// minSeedRPS is the lowest rate a limit seeded from the first 429 starts at
+	minSeedRPS = 1
+)
+
+// limiterStats represents client side rate limiting activity
+type limiterStats struct {
+	Requests    int
+	Throttled   int
+	Retries     int
+	Waited      time.Duration
+	CurrentRPS  float64
+	MaxInFlight int
+}
+
+// limiter is a http.RoundTripper combining a token bucket, a max-in-flight semaphore and adaptive 429 backoff. Without
+// a maxRPS the bucket is off until the first 429, which seeds maxRPS from the rate requests were sent at.
+type limiter struct {
+	next     http.RoundTripper
+	mux      sync.Mutex
+	maxRPS   float64
+	rate     float64
+	tokens   float64
+	last     time.Time
+	started  time.Time
+	inFlight chan struct{}
+	stats    limiterStats
+}
+
+func newLimiter(next http.RoundTripper, maxRPS float64, maxInFlight int) *limiter {
+	l := &limiter{next: next, maxRPS: maxRPS, rate: maxRPS, last: time.Now(), started: time.Now()}
+	if maxRPS > 0 {
+		l.tokens = math.Max(1, maxRPS)
+	}
+	if maxInFlight > 0 {
+		l.inFlight = make(chan struct{}, maxInFlight)
+	}
+	l.stats.MaxInFlight = maxInFlight
+	return l
+}
+
+// Stats returns the rate limiting activity so far
+func (l *limiter) Stats() limiterStats {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	stats := l.stats
+	stats.CurrentRPS = l.rate
+	return stats
+}
+
+// wait blocks until the token bucket allows another request and returns how long it waited
+func (l *limiter) wait() time.Duration {
+	var waited time.Duration
+	for {
+		l.mux.Lock()
+		if l.maxRPS <= 0 {
+			l.mux.Unlock()
+			return 0
+		}
+		now := time.Now()
+		burst := math.Max(1, l.rate)
+		l.tokens = math.Min(burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.stats.Waited += waited
+			l.mux.Unlock()
+			return waited
+		}
+		delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mux.Unlock()
+		time.Sleep(delay)
+		waited += delay
+	}
+}
+
+func (l *limiter) acquire() {
+	if l.inFlight != nil {
+		l.inFlight <- struct{}{}
+	}
+}
+
+func (l *limiter) release() {
+	if l.inFlight != nil {
+		<-l.inFlight
+	}
+}
+
+// throttled slows the token bucket down after a 429 and returns how long to back off
+func (l *limiter) throttled(res *http.Response, attempt int) time.Duration {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.stats.Throttled++
+	if l.maxRPS <= 0 {
+		// measured over a second at least, the first requests of a command are sent in a burst
+		l.maxRPS = math.Max(minSeedRPS, float64(l.stats.Requests)/math.Max(1, time.Since(l.started).Seconds()))
+		l.rate = l.maxRPS
+		l.last = time.Now()
+	}
+	l.rate = math.Max(l.maxRPS*minRateFraction, l.rate/2)
+	l.tokens = 0
+	if s, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second
+	}
+	backoff := minBackoff << uint(attempt)
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+// succeeded lets the token bucket recover towards maxRPS after a throttled period
+func (l *limiter) succeeded() {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if l.maxRPS > 0 && l.rate < l.maxRPS {
+		l.rate = math.Min(l.maxRPS, l.rate+l.maxRPS*minRateFraction)
+	}
+}
+
+// RoundTrip executes req within the rate and concurrency limits, retrying on 429
+func (l *limiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if waited := l.wait(); waited > 0 {
+			logf(logHeaders, "rate limiter delayed %s %s by %s", req.Method, redactURL(req.URL.String()), waited)
+		}
+		l.acquire()
+		l.mux.Lock()
+		l.stats.Requests++
+		l.mux.Unlock()
+
+		res, err := l.next.RoundTrip(req)
+		if err != nil {
+			l.release()
+			return nil, err
+		}
+		if res.StatusCode != http.StatusTooManyRequests || attempt >= maxThrottledRetries || (req.Body != nil && req.GetBody == nil) {
+			if res.StatusCode != http.StatusTooManyRequests {
+				l.succeeded()
+			}
+			// the response is read here so the slot is free whether or not, and when, the caller closes the body: the
+			// SDK closes the bodies of pages only when it has read all of them
+			b, err := ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			l.release()
+			if err != nil {
+				return nil, err
+			}
+			res.Body = ioutil.NopCloser(bytes.NewReader(b))
+			return res, nil
+		}
+
+		// drain the throttled response and retry with a fresh body
+		io.Copy(ioutil.Discard, res.Body)
+		res.Body.Close()
+		l.release()
+		backoff := l.throttled(res, attempt)
+		logf(logInfo, "%s %s was rate limited (429), retrying in %s at %.2f requests/second", req.Method, redactURL(req.URL.String()), backoff, l.Stats().CurrentRPS)
+		time.Sleep(backoff)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = cloneRequest(req)
+			req.Body = body
+		}
+		l.mux.Lock()
+		l.stats.Retries++
+		l.mux.Unlock()
+	}
+}
+
+// cloneRequest returns a shallow copy of req, a RoundTripper must not modify the request it was given
+func cloneRequest(req *http.Request) *http.Request {
+	r := new(http.Request)
+	*r = *req
+	return r
+}
+
+// printLimiterStats logs client side rate limiting in verbose mode
+func printLimiterStats() {
+	if rateLimiter == nil || verbosity() == 0 {
+		return
+	}
+	stats := rateLimiter.Stats()
+	level := logInfo
+	if stats.Throttled == 0 && stats.Waited == 0 {
+		level = logHeaders
+	}
+	logf(level, "Rate limiter: %d requests, %d throttled (429), %d retries, waited %s, %.2f requests/second, max in flight %d", stats.Requests, stats.Throttled, stats.Retries, stats.Waited, stats.CurrentRPS, stats.MaxInFlight)
+}
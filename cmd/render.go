@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var renderConfigCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Preview a Templated Config File",
+	Long: `Print a config file as --render uploads it, with the values of secrets masked. Nothing is uploaded or written.
+Config files are Go templates: {{.Vars.KEY}} reads the --vars file (JSON or YAML), {{.Env.NAME}} an environment variable,
+{{.TenantID}} the tenant of the configured login and {{secret "NAME"}} the environment variable NAME, else NAME in the --secrets file.
+{{json VALUE}} writes a value as JSON, quoted and escaped for strings. A missing key, variable or secret is an error.`,
+	Example: "  pi flow config render --file conf/config.json --vars conf/dev.yaml\n  pi flow config render --file conf/config.json --vars conf/prod.yaml --secrets ~/.pi/secrets.yaml",
+	Run: func(cmd *cobra.Command, args []string) {
+		err := getMissingRequiredParams(renderConfigPI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			os.Exit(exitError)
+		}
+		t, err := newConfigTemplate(renderConfigPI.V.GetString("vars"), renderConfigPI.V.GetString("secrets"), true)
+		if err != nil {
+			fmt.Println("error " + err.Error())
+			os.Exit(exitError)
+		}
+		b, err := t.render(renderConfigPI.V.GetString("file"))
+		if err != nil {
+			fmt.Println("error rendering config file err=" + err.Error())
+			os.Exit(exitError)
+		}
+		os.Stdout.Write(b)
+		cleanup(renderConfigPI)
+	},
+}
+
+// configTemplate is the data config files are rendered with by --render
+type configTemplate struct {
+	Vars     map[string]interface{}
+	Env      map[string]string
+	TenantID string
+
+	secrets map[string]string
+	// mask replaces the values of secrets, for previews
+	mask bool
+}
+
+// newConfigTemplate reads the vars and secrets files, either may be empty
+func newConfigTemplate(varsPath, secretsPath string, mask bool) (*configTemplate, error) {
+	t := &configTemplate{Vars: map[string]interface{}{}, Env: map[string]string{}, TenantID: loginPI.V.GetString("TenantID"), secrets: map[string]string{}, mask: mask}
+	for _, kv := range os.Environ() {
+		if i := strings.Index(kv, "="); i > 0 {
+			t.Env[kv[:i]] = kv[i+1:]
+		}
+	}
+	var err error
+	if varsPath != "" {
+		if t.Vars, err = readTemplateValues(varsPath); err != nil {
+			return nil, fmt.Errorf("reading vars err=%s", err.Error())
+		}
+	}
+	if secretsPath != "" {
+		secrets, err := readTemplateValues(secretsPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading secrets err=%s", err.Error())
+		}
+		for k, v := range secrets {
+			switch v.(type) {
+			case map[string]interface{}, []interface{}, nil:
+				return nil, fmt.Errorf("reading secrets err=secret %s in %s is not a string", k, secretsPath)
+			}
+			t.secrets[k] = fmt.Sprint(v)
+		}
+	}
+	return t, nil
+}
+
+// readTemplateValues decodes a JSON or YAML object of template values
+func readTemplateValues(path string) (map[string]interface{}, error) {
+	content, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := content.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s is not an object", path)
+	}
+	return values, nil
+}
+
+// secret returns the environment variable name, else name in the secrets file. Unless masked the value is redacted
+// from logged and recorded requests.
+func (t *configTemplate) secret(name string) (string, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		v, ok = t.secrets[name]
+	}
+	if !ok {
+		return "", fmt.Errorf("secret %s is neither an environment variable nor in the secrets file", name)
+	}
+	if t.mask {
+		return redactedValue, nil
+	}
+	escaped, _ := json.Marshal(v)
+	redactValues(v, strings.Trim(string(escaped), `"`))
+	return v, nil
+}
+
+// render executes the config file at path as a template, rendered JSON files must be valid JSON
+func (t *configTemplate) render(path string) ([]byte, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(filepath.Base(path)).Option("missingkey=error").Funcs(template.FuncMap{
+		"secret": t.secret,
+		"json":   templateJSON,
+	}).Parse(string(b))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, t); err != nil {
+		return nil, err
+	}
+	if isJSONFileName(path) && !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("%s is not valid JSON once rendered", path)
+	}
+	return append([]byte{}, buf.Bytes()...), nil
+}
+
+func templateJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// renderFileDetails renders the files of fileDetails by name, they are uploaded from memory
+func renderFileDetails(t *configTemplate, fileDetails []predixinsights.FileDetails) (map[string][]byte, error) {
+	rendered := map[string][]byte{}
+	for _, f := range fileDetails {
+		b, err := t.render(f.FileLocation)
+		if err != nil {
+			return nil, err
+		}
+		rendered[f.FileName] = b
+	}
+	return rendered, nil
+}
+
+// bypassCacheForSecrets keeps the responses of a rendered upload, which may hold secrets, out of the response cache
+func bypassCacheForSecrets() {
+	viper.Set("no-cache", true)
+}
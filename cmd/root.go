@@ -18,6 +18,9 @@ concentrate on building analytical pipelines, not managing infrastructure.`,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	//Run: func(cmd *cobra.Command, args []string) {},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		printLimiterStats()
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -214,6 +217,7 @@ func init() {
 			stringVar{&flowTemplateVersion, "flowTemplateVersion", "", "", "Flow Template Version", "FLOW_TEMPLATE_VERSION", true},
 			stringVar{&desc, "desc", "", "", "Flow Template Description", "DESC", true},
 			stringVar{&flowType, "flowType", "", "", "Flow Type (SPARK_JAVA or SPARK_PYTHON)", "FLOW_TYPE", true},
+			stringVar{&tags, "tags", "", "", "Flow Template Tags as a JSON array", "TAGS", false},
 		},
 		[]boolVar{},
 		[]intVar{})
@@ -240,7 +244,10 @@ func init() {
 			stringVar{&flowTemplateID, "flowTemplateID", "", "", "Flow Template ID", "FLOW_TEMPLATE_ID", true},
 			stringVar{&sparkArgs, "sparkArgs", "", "", "Flow Encapsulated Spark Arguments", "SPARK_ARGS", true},
 		},
-		[]boolVar{},
+		[]boolVar{
+			boolVar{&merge, "merge", "", false, "Patch the current spark arguments with --sparkArgs instead of replacing them", "MERGE", false},
+			boolVar{&replace, "replace", "", false, "Build the spark arguments from empty ones instead of the current ones", "REPLACE", false},
+		},
 		[]intVar{})
 	// list
 	getFlowTemplatePI = NewPI(
@@ -282,6 +289,15 @@ func init() {
 		},
 		[]boolVar{},
 		[]intVar{})
+	// edit
+	editFlowTemplatePI = NewPI(
+		flowTemplateCmd,
+		editFlowTemplateCmd,
+		[]stringVar{
+			stringVar{&flowTemplateID, "flowTemplateID", "", "", "Flow Template ID", "FLOW_TEMPLATE_ID", true},
+		},
+		[]boolVar{},
+		[]intVar{})
 
 	// FLOW Commands
 	// list
@@ -302,6 +318,8 @@ func init() {
 		[]stringVar{
 			stringVar{&flowName, "flowName", "", "", "Flow Name", "FLOW_NAME", true},
 			stringVar{&flowTemplateID, "flowTemplateID", "", "", "Flow Template ID", "FLOW_TEMPLATE_ID", true},
+			stringVar{&tags, "tags", "", "", "Flow Tags as a JSON array", "TAGS", false},
+			stringVar{&ttl, "ttl", "", "", "Time to live, e.g. 72h, after which pi gc deletes the flow", "TTL", false},
 		},
 		[]boolVar{},
 		[]intVar{})
@@ -316,6 +334,29 @@ func init() {
 			stringVar{&flowVersion, "flowVersion", "", "", "Direct Flow Version", "FLOW_VERSION", true},
 			stringVar{&desc, "desc", "", "", "Flow Template Description", "DESC", true},
 			stringVar{&flowType, "flowType", "", "", "Flow Type (SPARK_JAVA or SPARK_PYTHON)", "FLOW_TYPE", true},
+			stringVar{&tags, "tags", "", "", "Flow Tags as a JSON array", "TAGS", false},
+			stringVar{&ttl, "ttl", "", "", "Time to live, e.g. 72h, after which pi gc deletes the flow", "TTL", false},
+		},
+		[]boolVar{},
+		[]intVar{})
+	// clone
+	cloneFlowPI = NewPI(
+		flowCmd,
+		cloneFlowCmd,
+		[]stringVar{
+			stringVar{&flowID, "flowID", "", "", "ID of the Flow to clone", "FLOW_ID", true},
+			stringVar{&cloneName, "name", "", "", "Name of the new Flow", "CLONE_NAME", true},
+			stringVar{&flowTemplateID, "flowTemplateID", "", "", "Flow Template ID of the new Flow, defaults to the template of the source", "FLOW_TEMPLATE_ID", false},
+			stringVar{&flowFilePath, "flowFilePath", "", "", "Artifact to clone a direct flow as a direct flow", "FLOW_FILE_PATH", false},
+		},
+		[]boolVar{},
+		[]intVar{})
+	// edit
+	editFlowPI = NewPI(
+		flowCmd,
+		editFlowCmd,
+		[]stringVar{
+			stringVar{&flowID, "flowID", "", "", "Flow ID", "FLOW_ID", true},
 		},
 		[]boolVar{},
 		[]intVar{})
@@ -335,8 +376,13 @@ func init() {
 		[]stringVar{
 			stringVar{&configFileDetails, "configFileDetails", "", "", "Flow Config File Details", "CONFIG_FILE_DETAILS", true},
 			stringVar{&flowID, "flowID", "", "", "Flow ID", "FLOW_ID", true},
+			stringVar{&varsFile, "vars", "", "", "JSON or YAML file of template values for --render", "VARS", false},
+			stringVar{&secretsFile, "secrets", "", "", "JSON or YAML file of secrets for --render, environment variables win", "SECRETS", false},
+			stringVar{&templateFilePath, "templateFilePath", "", "", "Local copy of the flow template zip holding the schemas declared by tags", "TEMPLATE_FILE_PATH", false},
+		},
+		[]boolVar{
+			boolVar{&renderConfig, "render", "", false, "Render the files as templates before uploading them", "RENDER", false},
 		},
-		[]boolVar{},
 		[]intVar{})
 	// list-config-file
 	listConfigFilesPI = NewPI(
@@ -377,7 +423,10 @@ func init() {
 			stringVar{&flowID, "flowID", "", "", "Flow ID", "FLOW_ID", true},
 			stringVar{&flowTemplateID, "flowTemplateID", "", "", "Flow Template ID", "FLOW_TEMPLATE_ID", true},
 		},
-		[]boolVar{},
+		[]boolVar{
+			boolVar{&merge, "merge", "", false, "Patch the current spark arguments with --sparkArgs instead of replacing them", "MERGE", false},
+			boolVar{&replace, "replace", "", false, "Build the spark arguments from empty ones instead of the current ones", "REPLACE", false},
+		},
 		[]intVar{})
 	// update
 	updateDirectFlowPI = NewPI(
@@ -398,9 +447,16 @@ func init() {
 		[]stringVar{
 			stringVar{&flowID, "flowID", "", "", "Flow ID", "FLOW_ID", true},
 			stringVar{&flowTemplateID, "flowTemplateID", "", "", "Flow Template ID", "FLOW_TEMPLATE_ID", true},
+			stringVar{&waitTimeout, "timeout", "", "", "Give up waiting after this duration, e.g. 30m", "WAIT_TIMEOUT", false},
+			stringVar{&pollInterval, "poll-interval", "", "10s", "Time between polls of the instance", "POLL_INTERVAL", false},
+			stringVar{&templateFilePath, "templateFilePath", "", "", "Local copy of the flow template zip holding the schemas declared by tags", "TEMPLATE_FILE_PATH", false},
 		},
-		[]boolVar{},
-		[]intVar{})
+		[]boolVar{
+			boolVar{&wait, "wait", "", false, "Wait for the instance to finish, exit code 0 only when it succeeded", "WAIT", false},
+		},
+		[]intVar{
+			intVar{&stderrLines, "stderr-lines", "", 0, "Lines of driver stderr printed when the instance did not succeed", "STDERR_LINES", false},
+		})
 	// stop
 	stopFlowPI = NewPI(
 		flowCmd,
@@ -544,6 +600,19 @@ func init() {
 		},
 		[]boolVar{},
 		[]intVar{})
+	// wait
+	waitInstancePI = NewPI(
+		instanceCmd,
+		waitInstanceCmd,
+		[]stringVar{
+			stringVar{&instanceID, "instanceID", "", "", "Instance ID", "INSTANCE_ID", true},
+			stringVar{&waitTimeout, "timeout", "", "", "Give up waiting after this duration, e.g. 30m", "WAIT_TIMEOUT", false},
+			stringVar{&pollInterval, "poll-interval", "", "10s", "Time between polls of the instance", "POLL_INTERVAL", false},
+		},
+		[]boolVar{},
+		[]intVar{
+			intVar{&stderrLines, "stderr-lines", "", 0, "Lines of driver stderr printed when the instance did not succeed", "STDERR_LINES", false},
+		})
 	//list-tasks
 	getAllTasksByStagePI = NewPI(
 		instanceCmd,
@@ -557,25 +626,286 @@ func init() {
 		[]boolVar{},
 		[]intVar{})
 
+	// CACHE Commands
+	// clear
+	clearCachePI = NewPI(cacheCmd, clearCacheCmd, []stringVar{}, []boolVar{}, []intVar{})
+
+	// APPLY Commands
+	// apply
+	applyPI = NewPI(
+		RootCmd,
+		applyCmd,
+		[]stringVar{
+			stringVar{&manifestFile, "file", "f", "", "Manifest file (YAML or JSON)", "MANIFEST_FILE", true},
+		},
+		[]boolVar{
+			boolVar{&planOnly, "plan", "", false, "Print the changes without applying them, exit code 2 when changes are pending", "PLAN", false},
+			boolVar{&prune, "prune", "", false, "Delete flows of the manifest's flow templates that are not in the manifest", "PRUNE", false},
+		},
+		[]intVar{})
+
+	// EXPORT Commands
+	// export
+	exportPI = NewPI(
+		RootCmd,
+		exportCmd,
+		[]stringVar{
+			stringVar{&exportDir, "dir", "", "pi-export", "Directory the manifests are written to", "EXPORT_DIR", false},
+			stringVar{&exportNames, "name", "", "", "Comma separated names of the resources to export", "EXPORT_NAME", false},
+			stringVar{&exportIDs, "id", "", "", "Comma separated IDs of the resources to export", "EXPORT_ID", false},
+			stringVar{&exportTags, "tag", "", "", "Comma separated tags of the flow templates and flows to export", "EXPORT_TAG", false},
+		},
+		[]boolVar{},
+		[]intVar{})
+
+	// BACKUP Commands
+	// create
+	createBackupPI = NewPI(
+		backupCmd,
+		createBackupCmd,
+		[]stringVar{
+			stringVar{&backupArchive, "file", "f", "", "Archive to write, defaults to pi-backup-<time>.tar.gz", "BACKUP_FILE", false},
+			stringVar{&artifactDir, "artifactDir", "", "", "Directory holding the flow template artifacts, DAG files and dependency files to include", "ARTIFACT_DIR", false},
+		},
+		[]boolVar{},
+		[]intVar{})
+	// restore
+	restoreBackupPI = NewPI(
+		backupCmd,
+		restoreBackupCmd,
+		[]stringVar{
+			stringVar{&backupArchive, "file", "f", "", "Archive created with pi backup create", "BACKUP_FILE", true},
+			stringVar{&idMapFile, "idMap", "", "", "File the mapping from backed up IDs to restored IDs is written to", "ID_MAP", false},
+		},
+		[]boolVar{
+			boolVar{&planOnly, "plan", "", false, "Print the changes without restoring them", "PLAN", false},
+		},
+		[]intVar{})
+
+	// PROMOTE Commands
+	// promote
+	promotePI = NewPI(
+		RootCmd,
+		promoteCmd,
+		[]stringVar{
+			stringVar{&promoteFrom, "from", "", "", "Source environment, a config file name in ~/.pi or a path", "PROMOTE_FROM", true},
+			stringVar{&promoteTo, "to", "", "", "Target environment, a config file name in ~/.pi or a path", "PROMOTE_TO", true},
+			stringVar{&exportNames, "name", "", "", "Comma separated names of the flow templates and flows to promote", "PROMOTE_NAME", false},
+			stringVar{&exportIDs, "id", "", "", "Comma separated source IDs of the flow templates and flows to promote", "PROMOTE_ID", false},
+			stringVar{&exportTags, "tag", "", "", "Comma separated tags of the flow templates and flows to promote", "PROMOTE_TAG", false},
+			stringVar{&overlayFile, "overlay", "", "", "Overlay file patching spark arguments, tags and config files for the target", "OVERLAY", false},
+			stringVar{&artifactDir, "artifactDir", "", "", "Directory holding the flow template artifacts", "ARTIFACT_DIR", false},
+		},
+		[]boolVar{
+			boolVar{&planOnly, "plan", "", false, "Print the changes without promoting them, exit code 2 when changes are pending", "PLAN", false},
+			boolVar{&force, "force", "f", false, "Promote without asking for confirmation", "FORCE", false},
+		},
+		[]intVar{})
+
+	// SWEEP Commands
+	// sweep
+	sweepPI = NewPI(
+		RootCmd,
+		sweepCmd,
+		[]stringVar{
+			stringVar{&sweepFile, "file", "f", "", "Sweep file (YAML or JSON)", "SWEEP_FILE", true},
+			stringVar{&resultsFile, "results", "", "", "CSV file the results table is also written to", "SWEEP_RESULTS", false},
+		},
+		[]boolVar{
+			boolVar{&keep, "keep", "", false, "Keep the ephemeral flows", "KEEP", false},
+		},
+		[]intVar{
+			intVar{&concurrency, "concurrency", "", 0, "Runs in flight at a time, overrides the sweep file", "CONCURRENCY", false},
+		})
+
+	// GC Commands
+	// gc
+	gcPI = NewPI(
+		RootCmd,
+		gcCmd,
+		[]stringVar{
+			stringVar{&minAge, "min-age", "", "24h", "Keep flow templates without flows created within this duration", "MIN_AGE", false},
+		},
+		[]boolVar{
+			boolVar{&yes, "yes", "y", false, "Delete without asking for confirmation", "YES", false},
+		},
+		[]intVar{})
+
+	// PRESET Commands
+	// list
+	listPresetsPI = NewPI(presetCmd, listPresetsCmd, []stringVar{}, []boolVar{}, []intVar{})
+	// show
+	showPresetPI = NewPI(
+		presetCmd,
+		showPresetCmd,
+		[]stringVar{
+			stringVar{&presetName, "preset", "", "", "Preset name", "PRESET", true},
+		},
+		[]boolVar{},
+		[]intVar{})
+	// diff
+	diffPresetPI = NewPI(
+		presetCmd,
+		diffPresetCmd,
+		[]stringVar{
+			stringVar{&againstPreset, "against", "", "", "Preset to compare against", "AGAINST", false},
+			stringVar{&flowID, "flowID", "", "", "Flow ID to compare against", "FLOW_ID", false},
+			stringVar{&flowTemplateID, "flowTemplateID", "", "", "Flow Template ID to compare against", "FLOW_TEMPLATE_ID", false},
+		},
+		[]boolVar{},
+		[]intVar{})
+
+	// SPARK-ARGS Commands
+	// from-submit
+	fromSubmitPI = NewPI(
+		sparkArgsCmd,
+		fromSubmitCmd,
+		[]stringVar{
+			stringVar{&fromProperties, "from-properties", "", "", "Properties file like spark-defaults.conf, applied before the command", "FROM_PROPERTIES", false},
+			stringVar{&instanceID, "instanceID", "", "", "Instance ID whose spark-submit command is translated", "INSTANCE_ID", false},
+		},
+		[]boolVar{},
+		[]intVar{})
+	// to-submit
+	toSubmitPI = NewPI(
+		sparkArgsCmd,
+		toSubmitCmd,
+		[]stringVar{
+			stringVar{&flowID, "flowID", "", "", "Flow ID", "FLOW_ID", true},
+			stringVar{&submitMaster, "master", "", "local[*]", "Master of the spark-submit command, empty for none", "MASTER", false},
+		},
+		[]boolVar{},
+		[]intVar{})
+
+	// FLOW CONFIG Commands
+	// sync
+	syncConfigPI = NewPI(
+		flowConfigCmd,
+		syncConfigCmd,
+		[]stringVar{
+			stringVar{&flowID, "flowID", "", "", "Flow ID", "FLOW_ID", true},
+			stringVar{&configDir, "dir", "", "", "Directory of config files", "CONFIG_DIR", true},
+			stringVar{&includeGlobs, "include", "", "", "Comma separated globs of the file names to sync, all when empty", "INCLUDE", false},
+			stringVar{&excludeGlobs, "exclude", "", "", "Comma separated globs of the file names not to sync", "EXCLUDE", false},
+			stringVar{&varsFile, "vars", "", "", "JSON or YAML file of template values for --render", "VARS", false},
+			stringVar{&secretsFile, "secrets", "", "", "JSON or YAML file of secrets for --render, environment variables win", "SECRETS", false},
+			stringVar{&templateFilePath, "templateFilePath", "", "", "Local copy of the flow template zip holding the schemas declared by tags", "TEMPLATE_FILE_PATH", false},
+		},
+		[]boolVar{
+			boolVar{&prune, "prune", "", false, "Delete config files missing from the directory", "PRUNE", false},
+			boolVar{&planOnly, "plan", "", false, "Print the changes without syncing them, exit code 2 when changes are pending", "PLAN", false},
+			boolVar{&renderConfig, "render", "", false, "Render the files as templates before comparing and uploading them", "RENDER", false},
+		},
+		[]intVar{})
+
+	// get
+	getConfigPI = NewPI(
+		flowConfigCmd,
+		getConfigCmd,
+		[]stringVar{
+			stringVar{&flowID, "flowID", "", "", "Flow ID", "FLOW_ID", true},
+			stringVar{&configFile, "file", "", "", "Config file name", "CONFIG_FILE", true},
+			stringVar{&configOutput, "output", "o", "", "File to write the config file to instead of stdout", "OUTPUT", false},
+		},
+		[]boolVar{},
+		[]intVar{})
+	// pull
+	pullConfigPI = NewPI(
+		flowConfigCmd,
+		pullConfigCmd,
+		[]stringVar{
+			stringVar{&flowID, "flowID", "", "", "Flow ID", "FLOW_ID", true},
+			stringVar{&configDir, "dir", "", "", "Directory the config files are written to", "CONFIG_DIR", true},
+			stringVar{&includeGlobs, "include", "", "", "Comma separated globs of the file names to pull, all when empty", "INCLUDE", false},
+			stringVar{&excludeGlobs, "exclude", "", "", "Comma separated globs of the file names not to pull", "EXCLUDE", false},
+		},
+		[]boolVar{},
+		[]intVar{})
+	// diff
+	diffConfigPI = NewPI(
+		flowConfigCmd,
+		diffConfigCmd,
+		[]stringVar{
+			stringVar{&flowID, "flowID", "", "", "Flow ID", "FLOW_ID", true},
+			stringVar{&configFile, "file", "", "", "Config file name", "CONFIG_FILE", true},
+			stringVar{&localFile, "local", "", "", "Local JSON or YAML file, defaults to the config file name", "LOCAL", false},
+			stringVar{&diffFormat, "format", "", "unified", "Diff format, unified or semantic", "DIFF_FORMAT", false},
+		},
+		[]boolVar{},
+		[]intVar{})
+	// diff
+	diffFlowPI = NewPI(
+		flowCmd,
+		diffFlowCmd,
+		[]stringVar{
+			stringVar{&flowDiffFormat, "format", "", "side-by-side", "Diff format, side-by-side or json", "DIFF_FORMAT", false},
+		},
+		[]boolVar{
+			boolVar{&againstTemplate, "against-template", "", false, "Compare the flow with its flow template", "AGAINST_TEMPLATE", false},
+		},
+		[]intVar{})
+	// render
+	renderConfigPI = NewPI(
+		flowConfigCmd,
+		renderConfigCmd,
+		[]stringVar{
+			stringVar{&configFile, "file", "", "", "Local config file template", "CONFIG_FILE", true},
+			stringVar{&varsFile, "vars", "", "", "JSON or YAML file of template values", "VARS", false},
+			stringVar{&secretsFile, "secrets", "", "", "JSON or YAML file of secrets, environment variables win", "SECRETS", false},
+		},
+		[]boolVar{},
+		[]intVar{})
+
 	// list all commands
-	commands = []*pi{&loginPI, &healthCheckPI, &versionCheckPI, &getDagPI, &deleteDagPI, &postDagPI, &updateDagPI, &deployDagPI, &dagStatusPI, &getDagTaskRunPI, &getDagRunPI, &getDagTaskPI, &getDependencyPI, &deleteDependencyPI, &deployDependencyPI, &unDeployDependencyPI, &postDependencyPI, &postFlowTemplatePI, &updateFlowTemplatePI, &updateFlowTemplateChangeSparkArgumentsPI, &getFlowTemplatePI, &deleteFlowTemplatePI, &getFlowTemplateTagsPI, &saveFlowTemplateTagsPI, &getFlowPI, &postFlowPI, &postDirectFlowPI, &createFlowTemplateFromFlowPI, &addFlowConfigFilesPI, &listConfigFilesPI, &deleteFlowConfigFilePI, &deleteFlowPI, &updateFlowChangeSparkArgumentsPI, &updateDirectFlowPI, &postLaunchFlowPI, &stopFlowPI, &getFlowTagsPI, &saveFlowTagsPI, &getInstancePI, &getAllInstanceContainersPI, &getContainerLogsResponsePI, &getContainerLogsPI, &getInstanceSubmitLogsPI, &stopInstancePI, &getSparkAppDetailsPI, &getSparkExecutorDetailsPI, &getAllAppStagesPI, &getAllAttemptsPI, &getAttemptDetailsPI, &getAllTasksByStagePI}
+	commands = []*pi{&loginPI, &healthCheckPI, &versionCheckPI, &getDagPI, &deleteDagPI, &postDagPI, &updateDagPI, &deployDagPI, &dagStatusPI, &getDagTaskRunPI, &getDagRunPI, &getDagTaskPI, &getDependencyPI, &deleteDependencyPI, &deployDependencyPI, &unDeployDependencyPI, &postDependencyPI, &postFlowTemplatePI, &updateFlowTemplatePI, &updateFlowTemplateChangeSparkArgumentsPI, &getFlowTemplatePI, &deleteFlowTemplatePI, &getFlowTemplateTagsPI, &saveFlowTemplateTagsPI, &editFlowTemplatePI, &getFlowPI, &postFlowPI, &postDirectFlowPI, &cloneFlowPI, &editFlowPI, &createFlowTemplateFromFlowPI, &addFlowConfigFilesPI, &listConfigFilesPI, &deleteFlowConfigFilePI, &deleteFlowPI, &updateFlowChangeSparkArgumentsPI, &updateDirectFlowPI, &postLaunchFlowPI, &stopFlowPI, &getFlowTagsPI, &saveFlowTagsPI, &getInstancePI, &getAllInstanceContainersPI, &getContainerLogsResponsePI, &getContainerLogsPI, &getInstanceSubmitLogsPI, &stopInstancePI, &waitInstancePI, &getSparkAppDetailsPI, &getSparkExecutorDetailsPI, &getAllAppStagesPI, &getAllAttemptsPI, &getAttemptDetailsPI, &getAllTasksByStagePI, &clearCachePI, &applyPI, &exportPI, &createBackupPI, &restoreBackupPI, &promotePI, &sweepPI, &gcPI, &listPresetsPI, &showPresetPI, &diffPresetPI, &fromSubmitPI, &toSubmitPI, &syncConfigPI, &getConfigPI, &pullConfigPI, &diffConfigPI, &renderConfigPI, &diffFlowPI}
 
 	// GENERAL GLOBAL flags
 	RootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "", homeDir+"/.pi/"+file, "config file location")
 	viper.BindPFlag("config", RootCmd.PersistentFlags().Lookup("config"))
 	viper.BindEnv("config", "CONFIG")
-	RootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbosity")
+	RootCmd.PersistentFlags().CountVarP(&verbose, "verbose", "v", "Enable verbosity, repeat for more detail (-v requests, -vv headers, -vvv bodies)")
 	viper.BindPFlag("verbose", RootCmd.PersistentFlags().Lookup("verbose"))
 	viper.BindEnv("verbose", "VERBOSE")
 	RootCmd.PersistentFlags().BoolVarP(&interactive, "interactive", "i", false, "Enable interactive mode (prompt user for input)")
 	viper.BindPFlag("interactive", RootCmd.PersistentFlags().Lookup("interactive"))
 	viper.BindEnv("interactive", "INTERACTIVE")
+	RootCmd.PersistentFlags().StringVarP(&recordFile, "record", "", "", "Record all API exchanges, with secrets scrubbed, to a HAR file")
+	viper.BindPFlag("record", RootCmd.PersistentFlags().Lookup("record"))
+	viper.BindEnv("record", "RECORD")
+	RootCmd.PersistentFlags().StringVarP(&replayFile, "replay", "", "", "Serve API responses from a recorded HAR file instead of the network")
+	viper.BindPFlag("replay", RootCmd.PersistentFlags().Lookup("replay"))
+	viper.BindEnv("replay", "REPLAY")
+	RootCmd.PersistentFlags().BoolVarP(&dryRun, "dry-run", "", false, "Perform reads but print, instead of sending, the changes")
+	viper.BindPFlag("dry-run", RootCmd.PersistentFlags().Lookup("dry-run"))
+	viper.BindEnv("dry-run", "DRY_RUN")
+	RootCmd.PersistentFlags().Float64VarP(&maxRPS, "max-rps", "", 0, "Maximum API requests per second, 0 is unlimited (config file key maxRPS)")
+	viper.BindPFlag("maxRPS", RootCmd.PersistentFlags().Lookup("max-rps"))
+	viper.BindEnv("maxRPS", "MAX_RPS")
+	RootCmd.PersistentFlags().IntVarP(&maxInFlight, "max-in-flight", "", 0, "Maximum concurrent API requests, 0 is unlimited (config file key maxInFlight)")
+	viper.BindPFlag("maxInFlight", RootCmd.PersistentFlags().Lookup("max-in-flight"))
+	viper.BindEnv("maxInFlight", "MAX_IN_FLIGHT")
+	RootCmd.PersistentFlags().BoolVarP(&noCache, "no-cache", "", false, "Bypass the response cache enabled by the config file key cache")
+	viper.BindPFlag("no-cache", RootCmd.PersistentFlags().Lookup("no-cache"))
+	viper.BindEnv("no-cache", "NO_CACHE")
+	RootCmd.PersistentFlags().StringVarP(&policyFile, "policy", "", "", "Policy file enforced on create, update and launch, defaults to ~/.pi/policy.yaml")
+	viper.BindPFlag("policy", RootCmd.PersistentFlags().Lookup("policy"))
+	viper.BindEnv("policy", "POLICY")
+	RootCmd.PersistentFlags().BoolVarP(&overridePolicy, "override-policy", "", false, "Proceed despite spark argument and policy violations, the override is logged to ~/.pi/policy-overrides.log")
+	viper.BindPFlag("override-policy", RootCmd.PersistentFlags().Lookup("override-policy"))
+	viper.BindEnv("cache", "CACHE")
 
 	// configure command structure
 	for _, c := range commands {
 		c.parent.AddCommand(c.C)
 	}
 
+	// repeatable flags, read from their variables
+	postLaunchFlowCmd.PersistentFlags().StringArrayVar(&launchSets, "set", []string{}, "Override a spark argument for this launch, KEY=VALUE (repeatable)")
+	addSparkArgFlags(updateFlowChangeSparkArguments, &flowSparkArgFlags)
+	addSparkArgFlags(updateFlowTemplateChangeSparkArguments, &templateSparkArgFlags)
+	postFlowCmd.PersistentFlags().StringArrayVar(&createPresets, "preset", []string{}, "Spark argument preset applied to the arguments of the flow template, in order (repeatable)")
+	diffPresetCmd.PersistentFlags().StringArrayVar(&presetNames, "preset", []string{}, "Preset to apply, in order (repeatable)")
+
 	// set PI CLI version
 	RootCmd.Version = Version + "\ngit commit hash " + GitHash + "\ngit commit date " + GitDate
 
@@ -613,8 +943,8 @@ func initConfig() {
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
-		if viper.GetBool("verbose") {
-			fmt.Println("Using config file:", viper.ConfigFileUsed())
+		if verbosity() > 0 {
+			fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 		}
 	}
 
@@ -0,0 +1,617 @@
+package cmd
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+)
+
+const (
+	// schemaMappingFile maps config file names to local schema files, in ~/.pi for the user and in the nearest .pi of
+	// the working directory for the project
+	schemaMappingFile = "schemas.yaml"
+	// schemaTagPrefix declares a schema on a flow or template as schema:CONFIG_FILE=PATH_IN_TEMPLATE_ZIP
+	schemaTagPrefix = "schema:"
+	// maxSchemaDepth stops $ref cycles that do not descend into the document
+	maxSchemaDepth = 64
+)
+
+// configSchemas resolves the JSON Schemas declared for the config files of a flow
+type configSchemas struct {
+	// mappings are the local mapping files, the project one first
+	mappings []schemaMapping
+	// tags maps config file names to schema paths in the template zip, flow tags win over template tags
+	tags map[string]string
+	// zip is the local copy of the template zip and sum the sha256 the template records for its artifact
+	zip, sum string
+}
+
+// schemaMapping is read from a mapping file, the keys are config file names or globs and the values schema files
+// relative to the mapping file
+type schemaMapping struct {
+	path    string
+	schemas map[string]string
+}
+
+// loadConfigSchemas reads the local mapping files and the schema tags of a flow and its template, zip is the local
+// template zip the tags refer to
+func loadConfigSchemas(flowTags, templateTags []string, zip string) (*configSchemas, error) {
+	s := &configSchemas{tags: map[string]string{}, zip: zip, sum: orDefault(artifactTag(flowTags), artifactTag(templateTags))}
+	for _, path := range []string{projectPath(schemaMappingFile), filepath.Join(dir, schemaMappingFile)} {
+		if path == "" || len(s.mappings) > 0 && s.mappings[0].path == path {
+			continue
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		content, err := readConfigFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema mapping %s err=%s", path, err.Error())
+		}
+		m := schemaMapping{path: path, schemas: map[string]string{}}
+		object, ok := content.(map[string]interface{})
+		if !ok && content != nil {
+			return nil, fmt.Errorf("invalid schema mapping %s err=expected config file names mapped to schema files", path)
+		}
+		for name, schema := range object {
+			p, ok := schema.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid schema mapping %s err=schema of %s is not a file name", path, name)
+			}
+			if _, err := filepath.Match(name, ""); err != nil {
+				return nil, fmt.Errorf("invalid schema mapping %s err=%q %s", path, name, err.Error())
+			}
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(filepath.Dir(path), p)
+			}
+			m.schemas[name] = p
+		}
+		s.mappings = append(s.mappings, m)
+	}
+	for _, tags := range [][]string{templateTags, flowTags} {
+		for _, t := range tags {
+			if !strings.HasPrefix(t, schemaTagPrefix) {
+				continue
+			}
+			kv := strings.SplitN(strings.TrimPrefix(t, schemaTagPrefix), "=", 2)
+			if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+				return nil, fmt.Errorf("invalid tag %s, expected %sCONFIG_FILE=PATH_IN_TEMPLATE_ZIP", t, schemaTagPrefix)
+			}
+			s.tags[kv[0]] = kv[1]
+		}
+	}
+	return s, nil
+}
+
+// flowConfigSchemas loads the schemas declared for the config files of a flow, templateID is empty for direct flows
+func flowConfigSchemas(client *predixinsights.Client, flowTags []string, templateID, zip string) (*configSchemas, error) {
+	templateTags := []string{}
+	if templateID != "" {
+		template, err := client.GetFlowTemplate(templateID)
+		if err != nil {
+			return nil, err
+		}
+		templateTags = template.Tags
+	}
+	return loadConfigSchemas(flowTags, templateTags, zip)
+}
+
+// schema returns the schema declared for a config file and where it was read from, nil when none is declared.
+// A local mapping wins over the tags, an exact name over a glob.
+func (s *configSchemas) schema(name string) (interface{}, string, error) {
+	for _, m := range s.mappings {
+		path, ok := m.schemas[name]
+		if !ok {
+			for _, pattern := range sortedKeys(m.schemas) {
+				if matched, _ := filepath.Match(pattern, name); matched {
+					path, ok = m.schemas[pattern], true
+					break
+				}
+			}
+		}
+		if ok {
+			schema, err := readConfigFile(path)
+			if err != nil {
+				return nil, "", fmt.Errorf("schema of %s in %s err=%s", name, m.path, err.Error())
+			}
+			return schema, path, nil
+		}
+	}
+	path, ok := s.tags[name]
+	if !ok {
+		return nil, "", nil
+	}
+	source := fmt.Sprintf("%s in the template zip", path)
+	if s.zip == "" {
+		return nil, "", fmt.Errorf("schema of %s is %s, give the zip with --templateFilePath", name, source)
+	}
+	if s.sum != "" {
+		sum, err := fileSHA256(s.zip)
+		if err != nil {
+			return nil, "", err
+		}
+		if sum != s.sum {
+			return nil, "", fmt.Errorf("schema of %s is %s but %s is not the uploaded template zip", name, source, s.zip)
+		}
+	}
+	b, err := readZipFile(s.zip, path)
+	if err != nil {
+		return nil, "", fmt.Errorf("schema of %s err=%s", name, err.Error())
+	}
+	j, err := yamlToJSON(b)
+	if err != nil {
+		return nil, "", fmt.Errorf("schema of %s, %s is neither JSON nor YAML err=%s", name, path, err.Error())
+	}
+	var schema interface{}
+	if err := json.Unmarshal(j, &schema); err != nil {
+		return nil, "", err
+	}
+	return schema, path + " in " + s.zip, nil
+}
+
+// schemasOfFlow loads the schemas declared for the config files of the flow with id
+func schemasOfFlow(client *predixinsights.Client, id, zip string) (*configSchemas, error) {
+	flow, err := findFlow(client, id)
+	if err != nil {
+		return nil, err
+	}
+	return flowConfigSchemas(client, flowTags(flow.Tags), flow.FlowTemplate.ID, zip)
+}
+
+// validateFlowConfigFiles downloads the JSON config files of a flow that have a schema and validates them
+func validateFlowConfigFiles(client *predixinsights.Client, flowID string, s *configSchemas) error {
+	list, err := client.ListConfigFilesByFlowID(flowID)
+	if err != nil {
+		return err
+	}
+	docs := map[string]interface{}{}
+	for _, f := range list {
+		if f.Directory {
+			continue
+		}
+		schema, _, err := s.schema(f.FileName)
+		if err != nil {
+			return err
+		}
+		if schema == nil {
+			continue
+		}
+		if !isJSONFileName(f.FileName) {
+			fmt.Printf("%s is not validated, only JSON config files can be downloaded\n", f.FileName)
+			continue
+		}
+		content, err := downloadConfigFile(client, flowID, f.FileName)
+		if err != nil {
+			return fmt.Errorf("%s err=%s", f.FileName, err.Error())
+		}
+		docs[f.FileName] = content
+	}
+	return s.validate(docs)
+}
+
+// readZipFile reads the file at name in a zip archive
+func readZipFile(zipPath, name string) ([]byte, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s", zipPath, err.Error())
+	}
+	defer r.Close()
+	name = strings.TrimPrefix(strings.TrimPrefix(name, "./"), "/")
+	for _, f := range r.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return ioutil.ReadAll(rc)
+	}
+	return nil, fmt.Errorf("%s is not in %s", name, zipPath)
+}
+
+// validate checks config files, decoded JSON by name, against their schemas and prints every violation. The error
+// counts the files that do not match.
+func (s *configSchemas) validate(docs map[string]interface{}) error {
+	invalid := 0
+	for _, name := range sortedKeys(docs) {
+		schema, source, err := s.schema(name)
+		if err != nil {
+			return err
+		}
+		if schema == nil {
+			continue
+		}
+		violations := validateSchema(schema, docs[name])
+		if len(violations) == 0 {
+			continue
+		}
+		invalid++
+		fmt.Printf("%s does not match schema %s:\n", name, source)
+		for _, v := range violations {
+			fmt.Println("  " + v.String())
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d config file(s) do not match their schema", invalid)
+	}
+	return nil
+}
+
+// validateUploads validates config files about to be uploaded, files without a schema are not decoded
+func (s *configSchemas) validateUploads(fileDetails []predixinsights.FileDetails, rendered map[string][]byte) error {
+	docs := map[string]interface{}{}
+	for _, f := range fileDetails {
+		schema, _, err := s.schema(f.FileName)
+		if err != nil {
+			return err
+		}
+		if schema == nil {
+			continue
+		}
+		b, err := readUpload(f, rendered)
+		if err != nil {
+			return err
+		}
+		j, err := yamlToJSON(b)
+		if err != nil {
+			return fmt.Errorf("%s is neither JSON nor YAML err=%s", f.FileName, err.Error())
+		}
+		var doc interface{}
+		if err := json.Unmarshal(j, &doc); err != nil {
+			return fmt.Errorf("%s err=%s", f.FileName, err.Error())
+		}
+		docs[f.FileName] = doc
+	}
+	return s.validate(docs)
+}
+
+// schemaViolation is one way a document does not match a schema, at a JSON pointer into the document
+type schemaViolation struct {
+	pointer, message string
+}
+
+func (v schemaViolation) String() string {
+	return orDefault(v.pointer, "/") + ": " + v.message
+}
+
+// schemaValidator checks documents against a JSON Schema. It supports the validation keywords of drafts 4 to 7 except
+// format and $refs outside the schema, unknown keywords are ignored.
+type schemaValidator struct {
+	root       interface{}
+	violations []schemaViolation
+}
+
+// validateSchema returns every violation of doc against schema
+func validateSchema(schema, doc interface{}) []schemaViolation {
+	v := &schemaValidator{root: schema}
+	v.validate(schema, doc, "", 0)
+	return v.violations
+}
+
+// check validates doc without recording the violations, for anyOf, oneOf and not
+func (v *schemaValidator) check(schema, doc interface{}, pointer string, depth int) []schemaViolation {
+	sub := &schemaValidator{root: v.root}
+	sub.validate(schema, doc, pointer, depth)
+	return sub.violations
+}
+
+func (v *schemaValidator) fail(pointer, format string, args ...interface{}) {
+	v.violations = append(v.violations, schemaViolation{pointer, fmt.Sprintf(format, args...)})
+}
+
+func (v *schemaValidator) validate(schema, doc interface{}, pointer string, depth int) {
+	if depth > maxSchemaDepth {
+		v.fail(pointer, "schema nests too deep, is there a $ref cycle?")
+		return
+	}
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			v.fail(pointer, "no value is allowed")
+		}
+		return
+	case map[string]interface{}:
+		v.validateObject(s, doc, pointer, depth)
+	}
+}
+
+func (v *schemaValidator) validateObject(s map[string]interface{}, doc interface{}, pointer string, depth int) {
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := resolveRef(v.root, ref)
+		if err != nil {
+			v.fail(pointer, "%s", err.Error())
+			return
+		}
+		// keywords next to $ref are ignored, as before draft 2019-09
+		v.validate(target, doc, pointer, depth+1)
+		return
+	}
+
+	if t, ok := s["type"]; ok && !matchesType(t, doc) {
+		v.fail(pointer, "expected %s, got %s", typeNames(t), jsonType(doc))
+		return
+	}
+	if enum, ok := s["enum"].([]interface{}); ok && !containsJSON(enum, doc) {
+		v.fail(pointer, "%s is not one of %s", jsonString(doc), jsonString(enum))
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, doc) {
+		v.fail(pointer, "expected %s, got %s", jsonString(c), jsonString(doc))
+	}
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		subs, ok := s[key].([]interface{})
+		if !ok {
+			continue
+		}
+		matched := 0
+		for _, sub := range subs {
+			if key == "allOf" {
+				v.validate(sub, doc, pointer, depth+1)
+			} else if len(v.check(sub, doc, pointer, depth+1)) == 0 {
+				matched++
+			}
+		}
+		switch {
+		case key == "anyOf" && matched == 0:
+			v.fail(pointer, "does not match any schema of anyOf")
+		case key == "oneOf" && matched != 1:
+			v.fail(pointer, "matches %d schemas of oneOf, expected exactly one", matched)
+		}
+	}
+	if not, ok := s["not"]; ok && len(v.check(not, doc, pointer, depth+1)) == 0 {
+		v.fail(pointer, "matches the schema of not")
+	}
+
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		v.validateProperties(s, d, pointer, depth)
+	case []interface{}:
+		v.validateItems(s, d, pointer, depth)
+	case string:
+		n := utf8.RuneCountInString(d)
+		if min, ok := schemaNumber(s, "minLength"); ok && float64(n) < min {
+			v.fail(pointer, "%d characters, expected at least %s", n, formatNumber(min))
+		}
+		if max, ok := schemaNumber(s, "maxLength"); ok && float64(n) > max {
+			v.fail(pointer, "%d characters, expected at most %s", n, formatNumber(max))
+		}
+		if pattern, ok := s["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			switch {
+			case err != nil:
+				v.fail(pointer, "invalid pattern %s in the schema", pattern)
+			case !re.MatchString(d):
+				v.fail(pointer, "%s does not match the pattern %s", jsonString(d), pattern)
+			}
+		}
+	case float64:
+		v.validateNumber(s, d, pointer)
+	}
+}
+
+func (v *schemaValidator) validateProperties(s map[string]interface{}, d map[string]interface{}, pointer string, depth int) {
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				if _, ok := d[name]; !ok {
+					v.fail(pointer, "missing required property %s", jsonString(name))
+				}
+			}
+		}
+	}
+	if min, ok := schemaNumber(s, "minProperties"); ok && float64(len(d)) < min {
+		v.fail(pointer, "%d properties, expected at least %s", len(d), formatNumber(min))
+	}
+	if max, ok := schemaNumber(s, "maxProperties"); ok && float64(len(d)) > max {
+		v.fail(pointer, "%d properties, expected at most %s", len(d), formatNumber(max))
+	}
+	properties, _ := s["properties"].(map[string]interface{})
+	patterns, _ := s["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := s["additionalProperties"]
+	for _, name := range sortedKeys(d) {
+		p := pointer + "/" + escapePointer(name)
+		if names, ok := s["propertyNames"]; ok {
+			for _, violation := range v.check(names, name, p, depth+1) {
+				v.fail(p, "property name %s", violation.message)
+			}
+		}
+		matched := false
+		if sub, ok := properties[name]; ok {
+			matched = true
+			v.validate(sub, d[name], p, depth+1)
+		}
+		for _, pattern := range sortedKeys(patterns) {
+			if re, err := regexp.Compile(pattern); err == nil && re.MatchString(name) {
+				matched = true
+				v.validate(patterns[pattern], d[name], p, depth+1)
+			}
+		}
+		if matched || !hasAdditional {
+			continue
+		}
+		if allowed, ok := additional.(bool); ok && !allowed {
+			v.fail(p, "property is not allowed by the schema")
+			continue
+		}
+		v.validate(additional, d[name], p, depth+1)
+	}
+}
+
+func (v *schemaValidator) validateItems(s map[string]interface{}, d []interface{}, pointer string, depth int) {
+	if min, ok := schemaNumber(s, "minItems"); ok && float64(len(d)) < min {
+		v.fail(pointer, "%d items, expected at least %s", len(d), formatNumber(min))
+	}
+	if max, ok := schemaNumber(s, "maxItems"); ok && float64(len(d)) > max {
+		v.fail(pointer, "%d items, expected at most %s", len(d), formatNumber(max))
+	}
+	if unique, ok := s["uniqueItems"].(bool); ok && unique {
+	duplicates:
+		for i := range d {
+			for j := i + 1; j < len(d); j++ {
+				if reflect.DeepEqual(d[i], d[j]) {
+					v.fail(pointer, "items %d and %d are equal, expected unique items", i, j)
+					break duplicates
+				}
+			}
+		}
+	}
+	switch items := s["items"].(type) {
+	case []interface{}:
+		for i, e := range d {
+			p := pointer + "/" + strconv.Itoa(i)
+			if i < len(items) {
+				v.validate(items[i], e, p, depth+1)
+			} else if additional, ok := s["additionalItems"]; ok {
+				v.validate(additional, e, p, depth+1)
+			}
+		}
+	case nil:
+	default:
+		for i, e := range d {
+			v.validate(items, e, pointer+"/"+strconv.Itoa(i), depth+1)
+		}
+	}
+	if contains, ok := s["contains"]; ok {
+		for i, e := range d {
+			if len(v.check(contains, e, pointer+"/"+strconv.Itoa(i), depth+1)) == 0 {
+				return
+			}
+		}
+		v.fail(pointer, "no item matches the schema of contains")
+	}
+}
+
+func (v *schemaValidator) validateNumber(s map[string]interface{}, d float64, pointer string) {
+	// draft 4 makes minimum and maximum exclusive with booleans, later drafts give the bound itself
+	exclusiveMin, _ := s["exclusiveMinimum"].(bool)
+	exclusiveMax, _ := s["exclusiveMaximum"].(bool)
+	if min, ok := schemaNumber(s, "minimum"); ok {
+		if d < min || exclusiveMin && d == min {
+			v.fail(pointer, "%s is less than the minimum %s", formatNumber(d), formatNumber(min))
+		}
+	}
+	if max, ok := schemaNumber(s, "maximum"); ok {
+		if d > max || exclusiveMax && d == max {
+			v.fail(pointer, "%s is greater than the maximum %s", formatNumber(d), formatNumber(max))
+		}
+	}
+	if min, ok := schemaNumber(s, "exclusiveMinimum"); ok && d <= min {
+		v.fail(pointer, "%s is not greater than %s", formatNumber(d), formatNumber(min))
+	}
+	if max, ok := schemaNumber(s, "exclusiveMaximum"); ok && d >= max {
+		v.fail(pointer, "%s is not less than %s", formatNumber(d), formatNumber(max))
+	}
+	if m, ok := schemaNumber(s, "multipleOf"); ok && m > 0 {
+		if q := d / m; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(pointer, "%s is not a multiple of %s", formatNumber(d), formatNumber(m))
+		}
+	}
+}
+
+// resolveRef resolves a $ref within the root schema, a JSON pointer fragment
+func resolveRef(root interface{}, ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("$ref %s is not in the schema, only refs starting with # are supported", ref)
+	}
+	target := root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		switch t := target.(type) {
+		case map[string]interface{}:
+			next, ok := t[token]
+			if !ok {
+				return nil, fmt.Errorf("$ref %s is not in the schema", ref)
+			}
+			target = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil, fmt.Errorf("$ref %s is not in the schema", ref)
+			}
+			target = t[i]
+		default:
+			return nil, fmt.Errorf("$ref %s is not in the schema", ref)
+		}
+	}
+	return target, nil
+}
+
+// jsonType returns the JSON Schema type of a decoded JSON value, integer for whole numbers
+func jsonType(doc interface{}) string {
+	switch d := doc.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if d == math.Trunc(d) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// matchesType checks doc against the type keyword, a type name or a list of them
+func matchesType(t, doc interface{}) bool {
+	actual := jsonType(doc)
+	names, ok := t.([]interface{})
+	if !ok {
+		names = []interface{}{t}
+	}
+	for _, n := range names {
+		if n == actual || n == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+func typeNames(t interface{}) string {
+	names, ok := t.([]interface{})
+	if !ok {
+		return fmt.Sprint(t)
+	}
+	s := []string{}
+	for _, n := range names {
+		s = append(s, fmt.Sprint(n))
+	}
+	sort.Strings(s)
+	return strings.Join(s, " or ")
+}
+
+func containsJSON(values []interface{}, doc interface{}) bool {
+	for _, e := range values {
+		if reflect.DeepEqual(e, doc) {
+			return true
+		}
+	}
+	return false
+}
+
+func schemaNumber(s map[string]interface{}, key string) (float64, bool) {
+	n, ok := s[key].(float64)
+	return n, ok
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name, schema, doc string
+		want              []schemaViolation
+	}{
+		{"valid document", `{"type":"object","required":["a"]}`, `{"a":1}`, nil},
+		{"type at the root", `{"type":"string"}`, `1`,
+			[]schemaViolation{{"", "expected string, got integer"}}},
+		{"list of types", `{"type":["string","null"]}`, `true`,
+			[]schemaViolation{{"", "expected null or string, got boolean"}}},
+		{"required in a nested object", `{"properties":{"a":{"required":["b"]}}}`, `{"a":{}}`,
+			[]schemaViolation{{"/a", `missing required property "b"`}}},
+		{"enum", `{"enum":["x","y"]}`, `"z"`,
+			[]schemaViolation{{"", `"z" is not one of ["x","y"]`}}},
+		{"minimum inside array items", `{"properties":{"a":{"items":{"properties":{"b":{"minimum":1}}}}}}`, `{"a":[{"b":1},{"b":0}]}`,
+			[]schemaViolation{{"/a/1/b", "0 is less than the minimum 1"}}},
+		{"tuple items and additionalItems", `{"items":[{"type":"string"}],"additionalItems":false}`, `["a",1]`,
+			[]schemaViolation{{"/1", "no value is allowed"}}},
+		{"additionalProperties with an escaped name", `{"properties":{"a":{}},"additionalProperties":false}`, `{"a":1,"x/y~z":2}`,
+			[]schemaViolation{{"/x~1y~0z", "property is not allowed by the schema"}}},
+		{"violations in property order", `{"properties":{"b":{"maxLength":1},"a":{"multipleOf":2}}}`, `{"b":"xyz","a":3}`,
+			[]schemaViolation{{"/a", "3 is not a multiple of 2"}, {"/b", "3 characters, expected at most 1"}}},
+		{"$ref", `{"definitions":{"n":{"type":"number"}},"properties":{"a":{"$ref":"#/definitions/n"}}}`, `{"a":"s"}`,
+			[]schemaViolation{{"/a", "expected number, got string"}}},
+		{"$ref outside the schema", `{"$ref":"other.json#/n"}`, `1`,
+			[]schemaViolation{{"", "$ref other.json#/n is not in the schema, only refs starting with # are supported"}}},
+		{"$ref cycle", `{"$ref":"#"}`, `1`,
+			[]schemaViolation{{"", "schema nests too deep, is there a $ref cycle?"}}},
+		{"oneOf", `{"properties":{"a":{"oneOf":[{"type":"number"},{"minimum":0}]}}}`, `{"a":1}`,
+			[]schemaViolation{{"/a", "matches 2 schemas of oneOf, expected exactly one"}}},
+		{"propertyNames", `{"propertyNames":{"pattern":"^[a-z]+$"}}`, `{"A":1}`,
+			[]schemaViolation{{"/A", `property name "A" does not match the pattern ^[a-z]+$`}}},
+		{"uniqueItems and minItems", `{"uniqueItems":true,"minItems":3}`, `[1,1]`,
+			[]schemaViolation{{"", "2 items, expected at least 3"}, {"", "items 0 and 1 are equal, expected unique items"}}},
+		{"draft 4 exclusiveMaximum", `{"maximum":5,"exclusiveMaximum":true}`, `5`,
+			[]schemaViolation{{"", "5 is greater than the maximum 5"}}},
+		{"draft 6 exclusiveMinimum", `{"exclusiveMinimum":5}`, `5`,
+			[]schemaViolation{{"", "5 is not greater than 5"}}},
+	}
+	for _, test := range tests {
+		var schema, doc interface{}
+		if err := json.Unmarshal([]byte(test.schema), &schema); err != nil {
+			t.Fatalf("%s: schema err=%s", test.name, err.Error())
+		}
+		if err := json.Unmarshal([]byte(test.doc), &doc); err != nil {
+			t.Fatalf("%s: doc err=%s", test.name, err.Error())
+		}
+		if got := validateSchema(schema, doc); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+	"github.com/spf13/cobra"
+)
+
+// setSparkArgs applies key=value assignments to a copy of args, see setSparkArg
+func setSparkArgs(args predixinsights.SparkArguments, sets []string) (predixinsights.SparkArguments, error) {
+	updated, err := copySparkArgs(args)
+	if err != nil {
+		return updated, err
+	}
+	for _, set := range sets {
+		i := strings.Index(set, "=")
+		if i <= 0 {
+			return updated, fmt.Errorf("invalid --set %q, expected key=value", set)
+		}
+		if err := setSparkArg(&updated, set[:i], set[i+1:]); err != nil {
+			return updated, err
+		}
+	}
+	return updated, nil
+}
+
+// setSparkArg sets a field of args by its JSON name. Map fields take the entry after the first dot, e.g.
+// confs.spark.sql.shuffle.partitions, and list fields a JSON array or comma separated values.
+func setSparkArg(args *predixinsights.SparkArguments, key, value string) error {
+	name, entry := key, ""
+	if i := strings.Index(key, "."); i >= 0 {
+		name, entry = key[:i], key[i+1:]
+	}
+	v := reflect.ValueOf(args).Elem()
+	names := []string{}
+	for i := 0; i < v.NumField(); i++ {
+		tag := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		names = append(names, tag)
+		if tag != name {
+			continue
+		}
+		field := v.Field(i)
+		if field.Kind() != reflect.Map && entry != "" {
+			return fmt.Errorf("%s is not a map, %s cannot be set", name, key)
+		}
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Int:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s must be a number, got %q", name, value)
+			}
+			field.SetInt(int64(n))
+		case reflect.Slice:
+			list := []string{}
+			if strings.HasPrefix(strings.TrimSpace(value), "[") {
+				if err := json.Unmarshal([]byte(value), &list); err != nil {
+					return fmt.Errorf("%s must be a JSON array of strings err=%s", name, err.Error())
+				}
+			} else if value != "" {
+				list = strings.Split(value, ",")
+			}
+			field.Set(reflect.ValueOf(list))
+		case reflect.Map:
+			if entry == "" {
+				return fmt.Errorf("%s is a map, set an entry with %s.KEY=VALUE", name, name)
+			}
+			if field.IsNil() {
+				field.Set(reflect.MakeMap(field.Type()))
+			}
+			field.SetMapIndex(reflect.ValueOf(entry), reflect.ValueOf(value))
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown spark argument %s, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// copySparkArgs returns a deep copy of args
+func copySparkArgs(args predixinsights.SparkArguments) (predixinsights.SparkArguments, error) {
+	copied := predixinsights.SparkArguments{}
+	b, err := json.Marshal(args)
+	if err != nil {
+		return copied, err
+	}
+	err = json.Unmarshal(b, &copied)
+	return copied, err
+}
+
+// sparkArgFlags holds the typed spark argument flags of update-spark-args
+type sparkArgFlags struct {
+	className, driverMemory, executorMemory, driverJavaOptions, executorJavaOptions, logConf, fileName, frameworkName string
+	numExecutors, driverCores                                                                                         int
+	confs, executorEnv, systemProps, appArgs, listeners, presets                                                      []string
+}
+
+// addSparkArgFlags registers a flag for every spark argument on cmd, maps and lists are repeatable
+func addSparkArgFlags(cmd *cobra.Command, f *sparkArgFlags) {
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&f.className, "className", "", "Main class of the application")
+	flags.StringVar(&f.driverMemory, "driverMemory", "", "Driver memory, e.g. 2g")
+	flags.StringVar(&f.executorMemory, "executorMemory", "", "Executor memory, e.g. 4g")
+	flags.IntVar(&f.numExecutors, "numExecutors", 0, "Number of executors")
+	flags.IntVar(&f.driverCores, "driverCores", 0, "Number of driver cores")
+	flags.StringVar(&f.driverJavaOptions, "driverJavaOptions", "", "Extra JVM options of the driver")
+	flags.StringVar(&f.executorJavaOptions, "executorJavaOptions", "", "Extra JVM options of the executors")
+	flags.StringVar(&f.logConf, "logConf", "", "Log configuration")
+	flags.StringVar(&f.fileName, "fileName", "", "Application file in the flow template artifact")
+	flags.StringVar(&f.frameworkName, "frameworkName", "", "Framework name")
+	flags.StringArrayVar(&f.confs, "conf", []string{}, "Spark configuration entry KEY=VALUE (repeatable)")
+	flags.StringArrayVar(&f.executorEnv, "executor-env", []string{}, "Executor environment variable KEY=VALUE (repeatable)")
+	flags.StringArrayVar(&f.systemProps, "system-prop", []string{}, "Java system property KEY=VALUE (repeatable)")
+	flags.StringArrayVar(&f.appArgs, "app-arg", []string{}, "Application argument, in order (repeatable)")
+	flags.StringArrayVar(&f.listeners, "listener", []string{}, "Spark listener class (repeatable)")
+	flags.StringArrayVar(&f.presets, "preset", []string{}, "Spark argument preset, see pi preset, applied in order before the other flags (repeatable)")
+}
+
+// sparkArgFlagNames are the flags registered by addSparkArgFlags
+var sparkArgFlagNames = []string{"className", "driverMemory", "executorMemory", "numExecutors", "driverCores", "driverJavaOptions", "executorJavaOptions", "logConf", "fileName", "frameworkName", "conf", "executor-env", "system-prop", "app-arg", "listener", "preset"}
+
+// given reports whether any typed spark argument flag was given to cmd
+func (f *sparkArgFlags) given(cmd *cobra.Command) bool {
+	for _, name := range sparkArgFlagNames {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply sets the fields of the flags given to cmd, except --preset. Map flags add entries, list flags replace the list.
+func (f *sparkArgFlags) apply(cmd *cobra.Command, args *predixinsights.SparkArguments) error {
+	changed := cmd.Flags().Changed
+	strs := []struct {
+		name  string
+		value string
+		field *string
+	}{
+		{"className", f.className, &args.ClassName},
+		{"driverMemory", f.driverMemory, &args.DriverMemory},
+		{"executorMemory", f.executorMemory, &args.ExecutorMemory},
+		{"driverJavaOptions", f.driverJavaOptions, &args.DriverJavaOptions},
+		{"executorJavaOptions", f.executorJavaOptions, &args.ExecutorJavaOptions},
+		{"logConf", f.logConf, &args.LogConf},
+		{"fileName", f.fileName, &args.FileName},
+		{"frameworkName", f.frameworkName, &args.FrameworkName},
+	}
+	for _, s := range strs {
+		if changed(s.name) {
+			*s.field = s.value
+		}
+	}
+	if changed("numExecutors") {
+		args.NumExecutors = f.numExecutors
+	}
+	if changed("driverCores") {
+		args.DriverCores = f.driverCores
+	}
+	if changed("app-arg") {
+		args.ApplicationArgs = append([]string{}, f.appArgs...)
+	}
+	if changed("listener") {
+		args.SparkListeners = append([]string{}, f.listeners...)
+	}
+	maps := []struct {
+		name   string
+		values []string
+		field  *map[string]string
+	}{
+		{"conf", f.confs, &args.Confs},
+		{"executor-env", f.executorEnv, &args.ExecutorEnv},
+		{"system-prop", f.systemProps, &args.SystemProps},
+	}
+	for _, m := range maps {
+		for _, kv := range m.values {
+			i := strings.Index(kv, "=")
+			if i <= 0 {
+				return fmt.Errorf("invalid --%s %q, expected KEY=VALUE", m.name, kv)
+			}
+			if *m.field == nil {
+				*m.field = map[string]string{}
+			}
+			(*m.field)[kv[:i]] = kv[i+1:]
+		}
+	}
+	return nil
+}
+
+// resolveSparkArgs returns the arguments update-spark-args sends. Presets and the typed flags are layered on the current
+// arguments, with --sparkArgs as a JSON merge patch between them, unless --replace starts from empty arguments. A
+// --sparkArgs given alone replaces the arguments, or patches the current ones with --merge.
+// --sparkArgs is only read from the config when no typed flag is given, it may be JSON or YAML inline, @file or - for stdin.
+func resolveSparkArgs(cmd *cobra.Command, p pi, f *sparkArgFlags, current predixinsights.SparkArguments) (predixinsights.EncapsulatedSparkArgs, error) {
+	typed := f.given(cmd)
+	raw := ""
+	if cmd.Flags().Changed("sparkArgs") || !typed {
+		raw = p.V.GetString("sparkArgs")
+	}
+	if raw == "" && !typed {
+		return predixinsights.EncapsulatedSparkArgs{}, errors.New("give --sparkArgs, --preset or spark argument flags like --className")
+	}
+	replace, merge := p.V.GetBool("replace"), p.V.GetBool("merge")
+	if replace && merge {
+		return predixinsights.EncapsulatedSparkArgs{}, errors.New("give --merge or --replace, not both")
+	}
+	sparkArgs := predixinsights.EncapsulatedSparkArgs{}
+	if !typed && !merge && !replace {
+		err := decodeJSONFlag(p, "sparkArgs", &sparkArgs)
+		return sparkArgs, err
+	}
+	if !replace {
+		args, err := copySparkArgs(current)
+		if err != nil {
+			return sparkArgs, err
+		}
+		sparkArgs.SparkArgs = args
+	}
+	if err := applyPresets(&sparkArgs.SparkArgs, f.presets); err != nil {
+		return sparkArgs, err
+	}
+	if raw != "" {
+		patch := struct {
+			SparkArgs map[string]interface{} `json:"sparkArguments"`
+		}{}
+		if err := decodeJSONFlag(p, "sparkArgs", &patch); err != nil {
+			return sparkArgs, err
+		}
+		if err := patchSparkArgs(&sparkArgs.SparkArgs, patch.SparkArgs); err != nil {
+			return sparkArgs, err
+		}
+	}
+	err := f.apply(cmd, &sparkArgs.SparkArgs)
+	return sparkArgs, err
+}
@@ -0,0 +1,529 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+	"github.com/spf13/cobra"
+)
+
+// submitOptions maps the spark-submit options taking a value to the spark property they set, "" for options
+// without one. --class and --properties-file are handled by parseSubmit.
+var submitOptions = map[string]string{
+	"--master":               "spark.master",
+	"--deploy-mode":          "spark.submit.deployMode",
+	"--class":                "",
+	"--name":                 "spark.app.name",
+	"--jars":                 "spark.jars",
+	"--packages":             "spark.jars.packages",
+	"--exclude-packages":     "spark.jars.excludes",
+	"--repositories":         "spark.jars.repositories",
+	"--py-files":             "spark.submit.pyFiles",
+	"--files":                "spark.files",
+	"--archives":             "spark.archives",
+	"--conf":                 "",
+	"-c":                     "",
+	"--properties-file":      "",
+	"--driver-memory":        "spark.driver.memory",
+	"--driver-java-options":  "spark.driver.extraJavaOptions",
+	"--driver-library-path":  "spark.driver.extraLibraryPath",
+	"--driver-class-path":    "spark.driver.extraClassPath",
+	"--executor-memory":      "spark.executor.memory",
+	"--proxy-user":           "",
+	"--driver-cores":         "spark.driver.cores",
+	"--total-executor-cores": "spark.cores.max",
+	"--executor-cores":       "spark.executor.cores",
+	"--num-executors":        "spark.executor.instances",
+	"--queue":                "spark.yarn.queue",
+	"--principal":            "spark.kerberos.principal",
+	"--keytab":               "spark.kerberos.keytab",
+}
+
+// submitSwitches are the spark-submit options without a value and the property they set to true, "" for none
+var submitSwitches = map[string]string{
+	"--supervise": "spark.driver.supervise",
+	"--verbose":   "",
+	"-v":          "",
+}
+
+// ignoredSubmitProperties are set by Predix Insights when a flow is launched
+var ignoredSubmitProperties = []string{"spark.master", "spark.submit.deployMode"}
+
+// logConfProperty is the java system property holding the log configuration, see SparkArguments.LogConf
+const logConfProperty = "log4j.configuration"
+
+var sparkArgsCmd = &cobra.Command{
+	Use:   "spark-args",
+	Short: "Translate Spark Arguments",
+	Long:  `Translate between spark-submit command lines, spark-defaults.conf properties and Predix Insights spark arguments.`,
+}
+
+func init() {
+	RootCmd.AddCommand(sparkArgsCmd)
+}
+
+var fromSubmitCmd = &cobra.Command{
+	Use:   "from-submit [\"spark-submit ...\" | -]",
+	Short: "Spark Arguments from a spark-submit Command",
+	Long: `Print the spark arguments, as taken by --sparkArgs, equivalent to a spark-submit command line, a properties file like spark-defaults.conf or both.
+The command is given as one argument, after -- as separate arguments, as - for stdin or with --instanceID as the command an instance was submitted with.
+Like spark-submit, options such as --driver-memory win over --conf, which wins over --properties-file and --from-properties.
+Properties with a spark argument, e.g. spark.driver.memory, spark.executor.instances or spark.executorEnv.*, set it, the others become confs.
+-D options of the driver java options become systemProps, -Dlog4j.configuration logConf. --master and --deploy-mode are ignored, Predix Insights sets them.`,
+	Example: "  pi spark-args from-submit \"spark-submit --class org.apache.spark.examples.SparkPi --executor-memory 4g spark-examples.jar 1000\"\n  pi spark-args from-submit --from-properties spark-defaults.conf\n  pi spark-args from-submit --instanceID MY_INSTANCE_ID | pi flow update-spark-args --flowID MY_FLOW_ID --sparkArgs -",
+	Run: func(cmd *cobra.Command, args []string) {
+		words := args
+		switch {
+		case fromSubmitPI.V.GetString("instanceID") != "" && len(args) == 0 && fromSubmitPI.V.GetString("from-properties") == "":
+			client, err := login()
+			if err != nil {
+				fmt.Println("authentication error err=" + err.Error())
+				return
+			}
+			instance, err := client.GetInstance(fromSubmitPI.V.GetString("instanceID"))
+			if err != nil {
+				fmt.Println("error getting instance err=" + err.Error())
+				return
+			}
+			words = []string{instance.Summary.SubmitDetails.Command}
+		case len(args) == 1 && args[0] == "-":
+			b, err := ioutil.ReadAll(os.Stdin)
+			if err != nil {
+				fmt.Println("error reading stdin err=" + err.Error())
+				return
+			}
+			words = []string{string(b)}
+		case len(args) == 0 && fromSubmitPI.V.GetString("from-properties") == "":
+			fmt.Println("failed to get required parameters err=give a spark-submit command, --from-properties or --instanceID")
+			return
+		}
+		if len(words) == 1 {
+			split, err := splitCommandLine(words[0])
+			if err != nil {
+				fmt.Println("error parsing spark-submit command err=" + err.Error())
+				return
+			}
+			words = split
+		}
+		properties := [][2]string{}
+		if file := fromSubmitPI.V.GetString("from-properties"); file != "" {
+			var err error
+			properties, err = readPropertiesFile(file)
+			if err != nil {
+				fmt.Println("error reading properties err=" + err.Error())
+				return
+			}
+		}
+		sparkArgs, warnings, err := parseSubmit(words, properties)
+		for _, w := range warnings {
+			fmt.Fprintln(os.Stderr, "warning: "+w)
+		}
+		if err != nil {
+			fmt.Println("error parsing spark-submit command err=" + err.Error())
+			return
+		}
+		b, err := json.Marshal(sparkArgs)
+		if err != nil {
+			fmt.Println("error printing spark arguments err=" + err.Error())
+			return
+		}
+		prettyprint(b)
+	},
+}
+
+var toSubmitCmd = &cobra.Command{
+	Use:   "to-submit",
+	Short: "spark-submit Command of a Flow",
+	Long: `Print a spark-submit command running a Predix Insights Flow locally with the same spark arguments, the reverse of pi spark-args from-submit.
+The application file is the fileName of the spark arguments, relative to the flow template artifact.`,
+	Example: "  pi spark-args to-submit --flowID MY_FLOW_ID\n  pi spark-args to-submit --flowID MY_FLOW_ID --master yarn",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := login()
+		if err != nil {
+			fmt.Println("authentication error err=" + err.Error())
+			return
+		}
+		err = getMissingRequiredParams(toSubmitPI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			return
+		}
+		flow, err := findFlow(client, toSubmitPI.V.GetString("flowID"))
+		if err != nil {
+			fmt.Println("error getting flow err=" + err.Error())
+			return
+		}
+		fmt.Println(formatSubmit(submitCommand(flow.SparkArgs, toSubmitPI.V.GetString("master"))))
+		cleanup(toSubmitPI)
+	},
+}
+
+// parseSubmit translates the words of a spark-submit command line, after the properties of a properties file, to spark
+// arguments. Words before spark-submit, such as environment variables, are skipped. The warnings name what is dropped.
+func parseSubmit(words []string, properties [][2]string) (predixinsights.EncapsulatedSparkArgs, []string, error) {
+	sparkArgs := predixinsights.EncapsulatedSparkArgs{}
+	if len(words) > 0 {
+		start := -1
+		for i, w := range words {
+			if base := filepath.Base(w); base == "spark-submit" || base == "spark-submit.sh" {
+				start = i
+				break
+			}
+		}
+		if start < 0 {
+			return sparkArgs, nil, fmt.Errorf("not a spark-submit command: %s", joinCommandLine(words))
+		}
+		words = words[start+1:]
+	}
+	confs, options := [][2]string{}, [][2]string{}
+	className, primary := "", ""
+	appArgs := []string(nil)
+	warnings := []string{}
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if !strings.HasPrefix(w, "-") {
+			primary, appArgs = w, append([]string{}, words[i+1:]...)
+			break
+		}
+		if property, ok := submitSwitches[w]; ok {
+			if property != "" {
+				options = append(options, [2]string{property, "true"})
+			}
+			continue
+		}
+		name, value := w, ""
+		if j := strings.Index(w, "="); j > 0 && strings.HasPrefix(w, "--") {
+			name, value = w[:j], w[j+1:]
+		} else {
+			if _, ok := submitOptions[name]; ok {
+				if i+1 >= len(words) {
+					return sparkArgs, warnings, fmt.Errorf("%s needs a value", name)
+				}
+				i++
+				value = words[i]
+			}
+		}
+		property, ok := submitOptions[name]
+		if !ok {
+			return sparkArgs, warnings, fmt.Errorf("unknown spark-submit option %s", name)
+		}
+		switch name {
+		case "--class":
+			className = value
+		case "--conf", "-c":
+			j := strings.Index(value, "=")
+			if j <= 0 {
+				return sparkArgs, warnings, fmt.Errorf("invalid %s %q, expected KEY=VALUE", name, value)
+			}
+			confs = append(confs, [2]string{value[:j], value[j+1:]})
+		case "--properties-file":
+			p, err := readPropertiesFile(value)
+			if err != nil {
+				return sparkArgs, warnings, err
+			}
+			properties = append(properties, p...)
+		case "--proxy-user":
+			warnings = append(warnings, fmt.Sprintf("ignored %s %s, there is no spark argument for it", name, value))
+		default:
+			options = append(options, [2]string{property, value})
+		}
+	}
+	args := &sparkArgs.SparkArgs
+	for _, kv := range append(append(properties, confs...), options...) {
+		if containsString(ignoredSubmitProperties, kv[0]) {
+			warnings = append(warnings, fmt.Sprintf("ignored %s=%s, Predix Insights sets it", kv[0], kv[1]))
+			continue
+		}
+		if err := setSparkProperty(args, kv[0], kv[1]); err != nil {
+			return sparkArgs, warnings, err
+		}
+	}
+	if className != "" {
+		args.ClassName = className
+	}
+	if primary != "" {
+		args.FileName = primary
+		args.ApplicationArgs = appArgs
+	}
+	return sparkArgs, warnings, nil
+}
+
+// setSparkProperty sets the spark argument of a spark property, or the conf when there is none
+func setSparkProperty(args *predixinsights.SparkArguments, key, value string) error {
+	number := func() (int, error) {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("%s must be a number, got %q", key, value)
+		}
+		return n, nil
+	}
+	var err error
+	switch {
+	case key == "spark.driver.memory":
+		args.DriverMemory = value
+	case key == "spark.executor.memory":
+		args.ExecutorMemory = value
+	case key == "spark.executor.instances":
+		args.NumExecutors, err = number()
+	case key == "spark.driver.cores":
+		args.DriverCores, err = number()
+	case key == "spark.app.name":
+		args.FrameworkName = value
+	case key == "spark.executor.extraJavaOptions":
+		args.ExecutorJavaOptions = value
+	case key == "spark.extraListeners":
+		args.SparkListeners = nil
+		for _, l := range strings.Split(value, ",") {
+			if l = strings.TrimSpace(l); l != "" {
+				args.SparkListeners = append(args.SparkListeners, l)
+			}
+		}
+	case strings.HasPrefix(key, "spark.executorEnv."):
+		if args.ExecutorEnv == nil {
+			args.ExecutorEnv = map[string]string{}
+		}
+		args.ExecutorEnv[strings.TrimPrefix(key, "spark.executorEnv.")] = value
+	case key == "spark.driver.extraJavaOptions":
+		err = setDriverJavaOptions(args, value)
+	default:
+		if args.Confs == nil {
+			args.Confs = map[string]string{}
+		}
+		args.Confs[key] = value
+	}
+	return err
+}
+
+// setDriverJavaOptions splits the driver java options into logConf, systemProps for the other -D options and
+// driverJavaOptions for the rest, replacing all three
+func setDriverJavaOptions(args *predixinsights.SparkArguments, value string) error {
+	words, err := splitCommandLine(value)
+	if err != nil {
+		return fmt.Errorf("invalid driver java options %q err=%s", value, err.Error())
+	}
+	args.LogConf, args.SystemProps = "", nil
+	rest := []string{}
+	for _, w := range words {
+		if !strings.HasPrefix(w, "-D") || len(w) == 2 {
+			rest = append(rest, w)
+			continue
+		}
+		key, v := w[2:], ""
+		if i := strings.Index(key, "="); i >= 0 {
+			key, v = key[:i], key[i+1:]
+		}
+		if key == logConfProperty {
+			args.LogConf = v
+			continue
+		}
+		if args.SystemProps == nil {
+			args.SystemProps = map[string]string{}
+		}
+		args.SystemProps[key] = v
+	}
+	args.DriverJavaOptions = joinCommandLine(rest)
+	return nil
+}
+
+// submitCommand returns the words of the spark-submit command equivalent to args, run with master unless empty
+func submitCommand(args predixinsights.SparkArguments, master string) []string {
+	words := []string{"spark-submit"}
+	option := func(name, value string) {
+		if value != "" {
+			words = append(words, name, value)
+		}
+	}
+	conf := func(key, value string) {
+		words = append(words, "--conf", key+"="+value)
+	}
+	option("--master", master)
+	option("--class", args.ClassName)
+	option("--name", args.FrameworkName)
+	option("--driver-memory", args.DriverMemory)
+	if args.DriverCores != 0 {
+		option("--driver-cores", strconv.Itoa(args.DriverCores))
+	}
+	option("--executor-memory", args.ExecutorMemory)
+	if args.NumExecutors != 0 {
+		option("--num-executors", strconv.Itoa(args.NumExecutors))
+	}
+	javaOptions := []string{}
+	if args.DriverJavaOptions != "" {
+		javaOptions = append(javaOptions, args.DriverJavaOptions)
+	}
+	if args.LogConf != "" {
+		javaOptions = append(javaOptions, shellQuote("-D"+logConfProperty+"="+args.LogConf))
+	}
+	for _, k := range sortedKeys(args.SystemProps) {
+		javaOptions = append(javaOptions, shellQuote("-D"+k+"="+args.SystemProps[k]))
+	}
+	option("--driver-java-options", strings.Join(javaOptions, " "))
+	if args.ExecutorJavaOptions != "" {
+		conf("spark.executor.extraJavaOptions", args.ExecutorJavaOptions)
+	}
+	if len(args.SparkListeners) > 0 {
+		conf("spark.extraListeners", strings.Join(args.SparkListeners, ","))
+	}
+	for _, k := range sortedKeys(args.ExecutorEnv) {
+		conf("spark.executorEnv."+k, args.ExecutorEnv[k])
+	}
+	for _, k := range sortedKeys(args.Confs) {
+		conf(k, args.Confs[k])
+	}
+	if args.FileName != "" || len(args.ApplicationArgs) > 0 {
+		words = append(words, args.FileName)
+		words = append(words, args.ApplicationArgs...)
+	}
+	return words
+}
+
+// formatSubmit quotes the words of a spark-submit command for a shell, with every option and the application on a line
+func formatSubmit(words []string) string {
+	var b bytes.Buffer
+	value, options := false, true
+	for i, w := range words {
+		if i > 0 && options && !value {
+			b.WriteString(" \\\n  ")
+		} else if i > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(shellQuote(w))
+		switch {
+		case i == 0:
+		case value:
+			value = false
+		case !strings.HasPrefix(w, "-"):
+			options = false
+		default:
+			_, value = submitOptions[w]
+		}
+	}
+	return b.String()
+}
+
+// unquotedWord matches the words a shell takes as they are
+var unquotedWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes s for a POSIX shell when needed
+func shellQuote(s string) string {
+	if unquotedWord.MatchString(s) {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// joinCommandLine quotes and joins words, the reverse of splitCommandLine
+func joinCommandLine(words []string) string {
+	quoted := []string{}
+	for _, w := range words {
+		quoted = append(quoted, shellQuote(w))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// splitCommandLine splits s into words like a POSIX shell, without expansions: single quotes are literal, double quotes
+// and backslashes escape and a backslash before a newline continues the line
+func splitCommandLine(s string) ([]string, error) {
+	words := []string{}
+	var word bytes.Buffer
+	inWord := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			if i+1 >= len(s) {
+				return nil, errors.New("trailing backslash")
+			}
+			i++
+			if s[i] != '\n' {
+				word.WriteByte(s[i])
+				inWord = true
+			}
+		case c == '\'':
+			j := strings.IndexByte(s[i+1:], '\'')
+			if j < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			word.WriteString(s[i+1 : i+1+j])
+			i += j + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`\n", s[i+1]) >= 0 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, errors.New("unterminated double quote")
+			}
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// readPropertiesFile reads the properties of a file like spark-defaults.conf in order: a key and a value separated by
+// whitespace, = or :, # and ! start comments and a backslash at the end of a line continues it
+func readPropertiesFile(path string) ([][2]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	properties := [][2]string{}
+	scanner := bufio.NewScanner(f)
+	line := ""
+	for scanner.Scan() {
+		l := strings.TrimLeft(scanner.Text(), " \t")
+		if line == "" && (l == "" || strings.HasPrefix(l, "#") || strings.HasPrefix(l, "!")) {
+			continue
+		}
+		if strings.HasSuffix(l, "\\") {
+			line += strings.TrimSuffix(l, "\\")
+			continue
+		}
+		line += l
+		i := strings.IndexAny(line, " \t=:")
+		if i < 0 {
+			properties = append(properties, [2]string{line, ""})
+		} else {
+			value := strings.TrimLeft(line[i:], " \t")
+			if strings.HasPrefix(value, "=") || strings.HasPrefix(value, ":") {
+				value = strings.TrimLeft(value[1:], " \t")
+			}
+			properties = append(properties, [2]string{line[:i], strings.TrimRight(value, " \t")})
+		}
+		line = ""
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return properties, nil
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+)
+
+func TestSparkArgsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		args predixinsights.SparkArguments
+	}{
+		{"empty", predixinsights.SparkArguments{}},
+		{"python", predixinsights.SparkArguments{
+			FileName:        "main.py",
+			ApplicationArgs: []string{"--date", "2018-01-01"},
+		}},
+		{"every field", predixinsights.SparkArguments{
+			ClassName:           "org.apache.spark.examples.SparkPi",
+			FrameworkName:       "pi",
+			DriverMemory:        "2g",
+			DriverCores:         2,
+			ExecutorMemory:      "4g",
+			NumExecutors:        10,
+			DriverJavaOptions:   "-XX:+UseG1GC -Xss4m",
+			ExecutorJavaOptions: "-XX:+UseG1GC -verbose:gc",
+			LogConf:             "file:log4j.properties",
+			SystemProps:         map[string]string{"env": "prod", "region": "us-east"},
+			SparkListeners:      []string{"com.example.Listener", "com.example.Metrics"},
+			ExecutorEnv:         map[string]string{"TZ": "UTC", "JAVA_HOME": "/usr/lib/jvm/java-8"},
+			Confs:               map[string]string{"spark.sql.shuffle.partitions": "400", "spark.executor.cores": "4"},
+			FileName:            "spark-examples.jar",
+			ApplicationArgs:     []string{"1000"},
+		}},
+		{"quoting", predixinsights.SparkArguments{
+			ClassName:       "Main",
+			SystemProps:     map[string]string{"greeting": "it's a test", "path": "$HOME/x"},
+			Confs:           map[string]string{"spark.driver.extraClassPath": "/opt/lib/*", "spark.app.tag": "a \"quoted\" value"},
+			FileName:        "app with spaces.jar",
+			ApplicationArgs: []string{"", "two words", "it's", "`date`", "-flag", "back\\slash"},
+		}},
+	}
+	for _, test := range tests {
+		command := formatSubmit(submitCommand(test.args, ""))
+		words, err := splitCommandLine(command)
+		if err != nil {
+			t.Fatalf("%s: split %s err=%s", test.name, command, err.Error())
+		}
+		parsed, warnings, err := parseSubmit(words, nil)
+		if err != nil {
+			t.Fatalf("%s: parse %s err=%s", test.name, command, err.Error())
+		}
+		if len(warnings) > 0 {
+			t.Errorf("%s: unexpected warnings %v", test.name, warnings)
+		}
+		if !sameSparkArgs(test.args, parsed.SparkArgs) {
+			t.Errorf("%s: %s\nparsed %s\nwant   %s", test.name, command, jsonString(parsed.SparkArgs), jsonString(test.args))
+		}
+	}
+}
+
+func TestSubmitCommandRoundTrip(t *testing.T) {
+	commands := []string{
+		`spark-submit --class org.apache.spark.examples.SparkPi spark-examples.jar 100`,
+		`/opt/spark/bin/spark-submit --name=etl --driver-memory 1g --executor-memory=8g --num-executors 4 --executor-cores 2 \
+			--conf spark.sql.shuffle.partitions=800 --conf "spark.executorEnv.TZ=UTC" etl.py --input "s3://bucket/a b"`,
+		`SPARK_HOME=/opt/spark spark-submit --driver-java-options "-Dlog4j.configuration=log4j.properties -Dk=v -XX:+UseG1GC" --supervise app.jar`,
+		`spark-submit --jars a.jar,b.jar --py-files deps.zip --files conf.json --queue analytics app.py`,
+	}
+	for _, c := range commands {
+		words, err := splitCommandLine(c)
+		if err != nil {
+			t.Fatalf("split %s err=%s", c, err.Error())
+		}
+		first, _, err := parseSubmit(words, nil)
+		if err != nil {
+			t.Fatalf("parse %s err=%s", c, err.Error())
+		}
+		formatted := formatSubmit(submitCommand(first.SparkArgs, ""))
+		words, err = splitCommandLine(formatted)
+		if err != nil {
+			t.Fatalf("split %s err=%s", formatted, err.Error())
+		}
+		second, _, err := parseSubmit(words, nil)
+		if err != nil {
+			t.Fatalf("parse %s err=%s", formatted, err.Error())
+		}
+		if !sameSparkArgs(first.SparkArgs, second.SparkArgs) {
+			t.Errorf("%s\nbecame %s\nfirst  %s\nsecond %s", c, formatted, jsonString(first.SparkArgs), jsonString(second.SparkArgs))
+		}
+	}
+}
+
+func TestParseSubmit(t *testing.T) {
+	properties := [][2]string{
+		{"spark.master", "yarn"},
+		{"spark.driver.memory", "1g"},
+		{"spark.executor.memory", "2g"},
+		{"spark.executor.instances", "2"},
+		{"spark.eventLog.enabled", "true"},
+	}
+	words, err := splitCommandLine(`spark-submit --master local[2] --conf spark.executor.memory=3g --conf spark.driver.memory=3g --driver-memory 4g --proxy-user bob
+		--driver-java-options '-Xmx1g -Dlog4j.configuration=file:l.xml -Da=1 -Db' app.jar x`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, warnings, err := parseSubmit(words, properties)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := predixinsights.SparkArguments{
+		DriverMemory:      "4g",
+		ExecutorMemory:    "3g",
+		NumExecutors:      2,
+		DriverJavaOptions: "-Xmx1g",
+		LogConf:           "file:l.xml",
+		SystemProps:       map[string]string{"a": "1", "b": ""},
+		Confs:             map[string]string{"spark.eventLog.enabled": "true"},
+		FileName:          "app.jar",
+		ApplicationArgs:   []string{"x"},
+	}
+	if !sameSparkArgs(want, got.SparkArgs) {
+		t.Errorf("parsed %s\nwant   %s", jsonString(got.SparkArgs), jsonString(want))
+	}
+	if len(warnings) != 3 {
+		t.Errorf("expected warnings for spark.master twice and --proxy-user, got %v", warnings)
+	}
+
+	errors := map[string]string{
+		"ls -l":                                    "not a spark-submit command: ls -l",
+		"spark-submit --bogus 1 a.jar":             "unknown spark-submit option --bogus",
+		"spark-submit --class":                     "--class needs a value",
+		"spark-submit --conf nokey a.jar":          `invalid --conf "nokey", expected KEY=VALUE`,
+		"spark-submit --num-executors many a.jar":  `spark.executor.instances must be a number, got "many"`,
+		"spark-submit --conf spark.driver.cores=x": `spark.driver.cores must be a number, got "x"`,
+	}
+	for c, want := range errors {
+		words, _ := splitCommandLine(c)
+		if _, _, err := parseSubmit(words, nil); err == nil || err.Error() != want {
+			t.Errorf("%s: expected error %q, got %v", c, want, err)
+		}
+	}
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := map[string][]string{
+		`a  b	c`:                  {"a", "b", "c"},
+		`'a b' "c d" e\ f`:        {"a b", "c d", "e f"},
+		`'it'\''s' "say \"hi\""`:  {"it's", `say "hi"`},
+		`"$HOME \$x \a" ''`:       {`$HOME $x \a`, ""},
+		"a \\\n  b":               {"a", "b"},
+		`x'y'"z"`:                 {"xyz"},
+		`--conf "k=v w" --flag=1`: {"--conf", "k=v w", "--flag=1"},
+	}
+	for s, want := range tests {
+		got, err := splitCommandLine(s)
+		if err != nil {
+			t.Errorf("%s: err=%s", s, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %q, want %q", s, got, want)
+		}
+		if again, _ := splitCommandLine(joinCommandLine(got)); !reflect.DeepEqual(again, got) {
+			t.Errorf("%s: joined %s split to %q", s, joinCommandLine(got), again)
+		}
+	}
+	for _, s := range []string{`'a`, `"a`, `a\`} {
+		if _, err := splitCommandLine(s); err == nil {
+			t.Errorf("%s: expected an error", s)
+		}
+	}
+}
+
+func TestReadPropertiesFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "spark-defaults")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("# comment\n! comment\n\nspark.master   yarn\nspark.driver.memory=2g\n  spark.executor.memory : 4g  \nspark.driver.extraJavaOptions -Da=1 \\\n  -Db=2\nspark.flag\n")
+	f.Close()
+	got, err := readPropertiesFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]string{
+		{"spark.master", "yarn"},
+		{"spark.driver.memory", "2g"},
+		{"spark.executor.memory", "4g"},
+		{"spark.driver.extraJavaOptions", "-Da=1 -Db=2"},
+		{"spark.flag", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// sameSparkArgs compares spark arguments by their JSON, so empty and missing maps and lists are equal
+func sameSparkArgs(a, b predixinsights.SparkArguments) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+	"github.com/spf13/cobra"
+)
+
+// sweepTagPrefix tags the ephemeral flows of a sweep with its name, to find flows left by an interrupted sweep
+const sweepTagPrefix = "pi-sweep:"
+
+var sweepCmd = &cobra.Command{
+	Use:   "sweep",
+	Short: "Launch a Flow Template Across a Parameter Matrix",
+	Long: `Run a flow template once for every combination of the parameters in a sweep file.
+Each run gets an ephemeral flow, whose spark arguments are set like pi flow launch --set with {{parameter}} replaced, and is tracked to completion.
+The results table lists the parameters, instance ID, final status and duration of every run. Ephemeral flows are deleted afterwards unless --keep is given.
+The exit code is 0 when every run succeeded and 3 otherwise.`,
+	Example: "  pi sweep -f sweep.yaml\n  pi sweep -f sweep.yaml --concurrency 2 --results results.csv --keep",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := login()
+		if err != nil {
+			fmt.Println("authentication error err=" + err.Error())
+			os.Exit(exitError)
+		}
+		err = getMissingRequiredParams(sweepPI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			os.Exit(exitError)
+		}
+		s, err := loadSweep(sweepPI.V.GetString("file"))
+		if err != nil {
+			fmt.Println("error loading sweep err=" + err.Error())
+			os.Exit(exitError)
+		}
+		if n := sweepPI.V.GetInt("concurrency"); n > 0 {
+			s.Concurrency = n
+		}
+		results, err := s.run(client, sweepPI.V.GetBool("keep"))
+		if err != nil {
+			fmt.Println("error running sweep err=" + err.Error())
+			os.Exit(exitError)
+		}
+		fmt.Println()
+		s.print(results)
+		if path := sweepPI.V.GetString("results"); path != "" {
+			if err := s.writeCSV(path, results); err != nil {
+				fmt.Println("error writing results err=" + err.Error())
+				os.Exit(exitError)
+			}
+		}
+		for _, r := range results {
+			if r.Status != "SUCCEEDED" && r.Status != "DRY-RUN" {
+				os.Exit(exitInstanceFailed)
+			}
+		}
+	},
+}
+
+// sweep is read from the sweep file
+type sweep struct {
+	Template     string                   `json:"template"`
+	Name         string                   `json:"name,omitempty"`
+	Concurrency  int                      `json:"concurrency,omitempty"`
+	Timeout      string                   `json:"timeout,omitempty"`
+	PollInterval string                   `json:"pollInterval,omitempty"`
+	Parameters   map[string][]interface{} `json:"parameters"`
+	Set          map[string]string        `json:"set"`
+
+	timeout, interval time.Duration
+}
+
+// sweepResult is one row of the results table
+type sweepResult struct {
+	Params     map[string]string
+	FlowID     string
+	InstanceID string
+	Status     string
+	Duration   time.Duration
+	Error      string
+}
+
+func loadSweep(path string) (*sweep, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &sweep{Name: "sweep", Concurrency: 1, PollInterval: "30s"}
+	if err := decodeStrict(b, s); err != nil {
+		return nil, fmt.Errorf("invalid sweep %s err=%s", path, err.Error())
+	}
+	if s.Template == "" {
+		return nil, errors.New("template is missing")
+	}
+	if len(s.Parameters) == 0 {
+		return nil, errors.New("parameters are missing")
+	}
+	for name, values := range s.Parameters {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("parameter %s has no values", name)
+		}
+	}
+	if s.timeout, err = time.ParseDuration(orDefault(s.Timeout, "0")); err != nil {
+		return nil, fmt.Errorf("invalid timeout err=%s", err.Error())
+	}
+	if s.interval, err = time.ParseDuration(s.PollInterval); err != nil || s.interval <= 0 {
+		return nil, fmt.Errorf("invalid pollInterval %q", s.PollInterval)
+	}
+	if s.Concurrency < 1 {
+		s.Concurrency = 1
+	}
+	return s, nil
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// combinations returns the cartesian product of the parameters, in the order of their sorted names
+func (s *sweep) combinations() []map[string]string {
+	combos := []map[string]string{{}}
+	for _, name := range sortedKeys(s.Parameters) {
+		next := []map[string]string{}
+		for _, combo := range combos {
+			for _, value := range s.Parameters[name] {
+				c := map[string]string{name: fmt.Sprint(value)}
+				for k, v := range combo {
+					c[k] = v
+				}
+				next = append(next, c)
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+// sets renders the set assignments for one combination
+func (s *sweep) sets(params map[string]string) []string {
+	sets := []string{}
+	for _, key := range sortedKeys(s.Set) {
+		value := s.Set[key]
+		for name, v := range params {
+			value = strings.Replace(value, "{{"+name+"}}", v, -1)
+		}
+		sets = append(sets, key+"="+value)
+	}
+	return sets
+}
+
+// run launches every combination with at most Concurrency runs at a time
+func (s *sweep) run(client *predixinsights.Client, keep bool) ([]sweepResult, error) {
+	res, err := client.GetFlowTemplateByName(s.Template)
+	if err != nil {
+		return nil, err
+	}
+	var template *predixinsights.FlowTemplate
+	for i, ft := range res.Content {
+		if ft.Name == s.Template {
+			template = &res.Content[i]
+		}
+	}
+	if template == nil {
+		return nil, fmt.Errorf("flow template '%s' not found", s.Template)
+	}
+	templateID := template.ID
+	combos := s.combinations()
+	// fail on typos and policy violations before creating any flow, ephemeral flows are checked with the template's tags
+	for _, combo := range combos {
+		args, err := setSparkArgs(template.SparkArgs, s.sets(combo))
+		if err != nil {
+			return nil, err
+		}
+		if err := enforce("sweep run "+paramString(combo), template.Type, &args, userTags(template.Tags)); err != nil {
+			return nil, err
+		}
+	}
+
+	prefix := s.Name + "-" + time.Now().UTC().Format("20060102150405")
+	results := make([]sweepResult, len(combos))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan int)
+	for w := 0; w < s.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				name := fmt.Sprintf("%s-%03d", prefix, i+1)
+				r := s.runOne(client, templateID, name, combos[i], keep)
+				mu.Lock()
+				results[i] = r
+				line := fmt.Sprintf("[%d/%d] %s %s", i+1, len(combos), paramString(r.Params), r.Status)
+				if r.Error != "" {
+					line += " err=" + r.Error
+				}
+				fmt.Println(line)
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range combos {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+	return results, nil
+}
+
+func (s *sweep) runOne(client *predixinsights.Client, templateID, name string, params map[string]string, keep bool) sweepResult {
+	r := sweepResult{Params: params, Status: "ERROR"}
+	flow, err := client.PostFlow(name, templateID)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	r.FlowID = flow.ID
+	if !keep {
+		defer func() {
+			if err := client.DeleteFlowByFlowIDOnly(flow.ID); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: ephemeral flow %s was not deleted err=%s\n", name, err.Error())
+			}
+		}()
+	}
+	if _, err := client.SaveTagsForFlow(templateID, flow.ID, predixinsights.TagsArray{sweepTagPrefix + s.Name}); err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	args, err := setSparkArgs(flow.SparkArgs, s.sets(params))
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	if err := client.UpdateFlowChangeSparkArguments(templateID, flow.ID, predixinsights.EncapsulatedSparkArgs{SparkArgs: args}); err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	start := time.Now()
+	launch, err := client.LaunchFlow(templateID, flow.ID)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	r.InstanceID = launch.ID
+	if dryRunCreated(launch.ID) {
+		r.Status = "DRY-RUN"
+		return r
+	}
+	instance, err := waitForInstance(client, launch.ID, s.timeout, s.interval, ioutil.Discard)
+	r.Duration = time.Since(start)
+	if err == errWaitTimeout {
+		r.Status = "TIMEOUT"
+		if !keep {
+			// the flow cannot be deleted while its instance runs
+			client.StopInstance(launch.ID)
+		}
+		return r
+	}
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	r.Status = instance.Details.FinalApplicationStatus
+	return r
+}
+
+func (s *sweep) print(results []sweepResult) {
+	names := sortedKeys(s.Parameters)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(names, "\t"))+"\tINSTANCE ID\tSTATUS\tDURATION")
+	for _, r := range results {
+		row := []string{}
+		for _, n := range names {
+			row = append(row, r.Params[n])
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", strings.Join(row, "\t"), r.InstanceID, r.Status, r.Duration.Round(time.Second))
+	}
+	w.Flush()
+}
+
+func (s *sweep) writeCSV(path string, results []sweepResult) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	names := sortedKeys(s.Parameters)
+	w := csv.NewWriter(f)
+	w.Write(append(append([]string{}, names...), "instanceID", "flowID", "status", "durationSeconds", "error"))
+	for _, r := range results {
+		row := []string{}
+		for _, n := range names {
+			row = append(row, r.Params[n])
+		}
+		w.Write(append(row, r.InstanceID, r.FlowID, r.Status, fmt.Sprintf("%.0f", r.Duration.Seconds()), r.Error))
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// paramString formats parameters as sorted name=value pairs
+func paramString(params map[string]string) string {
+	pairs := []string{}
+	for k, v := range params {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
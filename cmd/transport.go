@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"net/http"
+
+	"github.com/spf13/viper"
+)
+
+// installTransport routes the requests of the SDK, which are sent with http.DefaultClient, through the transports of the
+// global flags: logging, rate limiting, dry run, the response cache and HAR recording or replay
+func installTransport() error {
+	if rateLimiter != nil {
+		return nil
+	}
+	transport, err := newTransport()
+	if err != nil {
+		return err
+	}
+	rateLimiter = newLimiter(transport, viper.GetFloat64("maxRPS"), viper.GetInt("maxInFlight"))
+	http.DefaultClient.Transport = &logTransport{next: rateLimiter}
+	return nil
+}
+
+// newTransport builds the transports below the rate limiter from the global flags
+func newTransport() (http.RoundTripper, error) {
+	var transport http.RoundTripper = http.DefaultTransport
+	if viper.GetString("replay") != "" {
+		replayer, err := newHARReplayer(viper.GetString("replay"))
+		if err != nil {
+			return nil, err
+		}
+		transport = replayer
+	}
+	if viper.GetString("record") != "" {
+		transport = newHARRecorder(transport, viper.GetString("record"))
+	}
+	if cacheEnabled() {
+		cache, err := newCacheTransport(transport)
+		if err != nil {
+			return nil, err
+		}
+		transport = cache
+	}
+	if viper.GetBool("dry-run") {
+		transport = &dryRunTransport{next: transport}
+	}
+	return transport, nil
+}
@@ -58,8 +58,65 @@ var (
 	containerLogSink                         int
 	tail                                     bool
 	cfgFile                                  string
-	verbose                                  bool
+	verbose                                  int
 	interactive                              bool
+	recordFile                               string
+	replayFile                               string
+	dryRun                                   bool
+	maxRPS                                   float64
+	maxInFlight                              int
+	noCache                                  bool
+	policyFile                               string
+	overridePolicy                           bool
+	manifestFile                             string
+	planOnly                                 bool
+	prune                                    bool
+	exportDir                                string
+	exportNames                              string
+	exportIDs                                string
+	exportTags                               string
+	backupArchive                            string
+	artifactDir                              string
+	idMapFile                                string
+	promoteFrom                              string
+	promoteTo                                string
+	overlayFile                              string
+	cloneName                                string
+	wait                                     bool
+	waitTimeout                              string
+	pollInterval                             string
+	stderrLines                              int
+	launchSets                               []string
+	sweepFile                                string
+	resultsFile                              string
+	keep                                     bool
+	concurrency                              int
+	ttl                                      string
+	minAge                                   string
+	yes                                      bool
+	merge                                    bool
+	replace                                  bool
+	flowSparkArgFlags                        sparkArgFlags
+	templateSparkArgFlags                    sparkArgFlags
+	presetName                               string
+	presetNames                              []string
+	createPresets                            []string
+	againstPreset                            string
+	fromProperties                           string
+	submitMaster                             string
+	configDir                                string
+	includeGlobs                             string
+	excludeGlobs                             string
+	configFile                               string
+	configOutput                             string
+	localFile                                string
+	diffFormat                               string
+	renderConfig                             bool
+	varsFile                                 string
+	secretsFile                              string
+	flowDiffFormat                           string
+	againstTemplate                          bool
+	rateLimiter                              *limiter
 	force                                    bool
 	Version                                  = "No Version Provided"
 	GitHash                                  = "No GitHash Provided"
@@ -91,6 +148,7 @@ var (
 	getFlowPI                                = pi{}
 	postFlowPI                               = pi{}
 	postDirectFlowPI                         = pi{}
+	cloneFlowPI                              = pi{}
 	createFlowTemplateFromFlowPI             = pi{}
 	addFlowConfigFilesPI                     = pi{}
 	listConfigFilesPI                        = pi{}
@@ -108,13 +166,35 @@ var (
 	getContainerLogsPI                       = pi{}
 	getInstanceSubmitLogsPI                  = pi{}
 	stopInstancePI                           = pi{}
+	waitInstancePI                           = pi{}
 	getSparkAppDetailsPI                     = pi{}
 	getSparkExecutorDetailsPI                = pi{}
 	getAllAppStagesPI                        = pi{}
 	getAllAttemptsPI                         = pi{}
 	getAttemptDetailsPI                      = pi{}
 	getAllTasksByStagePI                     = pi{}
-	flags                                    = []flag{flag{"APIHost", "string"}, flag{"ClientID", "string"}, flag{"ClientSecret", "string"}, flag{"IssuerID", "string"}, flag{"TenantID", "string"}, flag{"Token", "string"}, flag{"attemptID", "string"}, flag{"configFileDetails", "string"}, flag{"configFileName", "string"}, flag{"containerID", "string"}, flag{"containerLogSink", "int"}, flag{"dagDesc", "string"}, flag{"dagFileName", "string"}, flag{"dagFilePath", "string"}, flag{"dagFlowType", "string"}, flag{"dagName", "string"}, flag{"dagRunID", "string"}, flag{"dagTaskID", "string"}, flag{"dagTemplate", "string"}, flag{"dagVersion", "string"}, flag{"dependencyFileLocation", "string"}, flag{"dependencyFileName", "string"}, flag{"dependencyID", "string"}, flag{"dependencyType", "string"}, flag{"desc", "string"}, flag{"flowFileName", "string"}, flag{"flowFilePath", "string"}, flag{"flowID", "string"}, flag{"flowName", "string"}, flag{"flowTemplateID", "string"}, flag{"flowTemplateName", "string"}, flag{"flowTemplateVersion", "string"}, flag{"flowType", "string"}, flag{"flowVersion", "string"}, flag{"force", "bool"}, flag{"instanceID", "string"}, flag{"sparkArgs", "string"}, flag{"stageAttemptID", "string"}, flag{"stageID", "string"}, flag{"tags", "string"}, flag{"tail", "bool"}, flag{"templateFileName", "string"}, flag{"templateFilePath", "string"}, flag{"verbose", "bool"}, flag{"interactive", "bool"}}
+	clearCachePI                             = pi{}
+	applyPI                                  = pi{}
+	exportPI                                 = pi{}
+	createBackupPI                           = pi{}
+	restoreBackupPI                          = pi{}
+	promotePI                                = pi{}
+	sweepPI                                  = pi{}
+	gcPI                                     = pi{}
+	editFlowPI                               = pi{}
+	editFlowTemplatePI                       = pi{}
+	showPresetPI                             = pi{}
+	listPresetsPI                            = pi{}
+	diffPresetPI                             = pi{}
+	fromSubmitPI                             = pi{}
+	toSubmitPI                               = pi{}
+	syncConfigPI                             = pi{}
+	getConfigPI                              = pi{}
+	pullConfigPI                             = pi{}
+	diffConfigPI                             = pi{}
+	renderConfigPI                           = pi{}
+	diffFlowPI                               = pi{}
+	flags                                    = []flag{flag{"APIHost", "string"}, flag{"ClientID", "string"}, flag{"ClientSecret", "string"}, flag{"IssuerID", "string"}, flag{"TenantID", "string"}, flag{"Token", "string"}, flag{"attemptID", "string"}, flag{"configFileDetails", "string"}, flag{"configFileName", "string"}, flag{"containerID", "string"}, flag{"containerLogSink", "int"}, flag{"dagDesc", "string"}, flag{"dagFileName", "string"}, flag{"dagFilePath", "string"}, flag{"dagFlowType", "string"}, flag{"dagName", "string"}, flag{"dagRunID", "string"}, flag{"dagTaskID", "string"}, flag{"dagTemplate", "string"}, flag{"dagVersion", "string"}, flag{"dependencyFileLocation", "string"}, flag{"dependencyFileName", "string"}, flag{"dependencyID", "string"}, flag{"dependencyType", "string"}, flag{"desc", "string"}, flag{"flowFileName", "string"}, flag{"flowFilePath", "string"}, flag{"flowID", "string"}, flag{"flowName", "string"}, flag{"flowTemplateID", "string"}, flag{"flowTemplateName", "string"}, flag{"flowTemplateVersion", "string"}, flag{"flowType", "string"}, flag{"flowVersion", "string"}, flag{"force", "bool"}, flag{"instanceID", "string"}, flag{"sparkArgs", "string"}, flag{"stageAttemptID", "string"}, flag{"stageID", "string"}, flag{"tags", "string"}, flag{"tail", "bool"}, flag{"templateFileName", "string"}, flag{"templateFilePath", "string"}, flag{"verbose", "int"}, flag{"interactive", "bool"}, flag{"maxRPS", "string"}, flag{"maxInFlight", "string"}, flag{"cache", "string"}, flag{"cacheTTL", "string"}}
 	commands                                 = []*pi{}
 )
 
@@ -170,6 +250,17 @@ func posString(slice []string, element string) int {
 	return -1
 }
 
+// verbosity returns the number of -v flags given, accepting VERBOSE=true as -v
+func verbosity() int {
+	if v := viper.GetInt("verbose"); v > 0 {
+		return v
+	}
+	if viper.GetBool("verbose") {
+		return 1
+	}
+	return 0
+}
+
 func getInputString() (string, error) {
 	scanner := bufio.NewScanner(os.Stdin)
 	scanner.Scan()
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.build.ge.com/predix-data-services/predix-insights-go-sdk/predixinsights"
+	"github.com/spf13/cobra"
+)
+
+// maxPollErrors is the number of consecutive failed polls after which waiting is given up
+const maxPollErrors = 3
+
+var waitInstanceCmd = &cobra.Command{
+	Use:   "wait",
+	Short: "Wait for a Flow Instance",
+	Long: `Poll a Predix Insights Flow Instance until it finishes, printing progress and YARN state changes.
+Exit codes: 0 SUCCEEDED, 1 error, 3 FAILED, 4 KILLED, 5 timeout. --stderr-lines prints the end of the driver stderr when the instance did not succeed.`,
+	Example: "  pi instance wait --instanceID MY_INSTANCE_ID\n  pi instance wait --instanceID MY_INSTANCE_ID --timeout 30m --poll-interval 30s --stderr-lines 100",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := login()
+		if err != nil {
+			fmt.Println("authentication error err=" + err.Error())
+			os.Exit(exitError)
+		}
+		err = getMissingRequiredParams(waitInstancePI)
+		if err != nil {
+			fmt.Println("failed to get required parameters err=" + err.Error())
+			os.Exit(exitError)
+		}
+		code := waitAndReport(client, waitInstancePI, waitInstancePI.V.GetString("instanceID"))
+		cleanup(waitInstancePI)
+		if code != 0 {
+			os.Exit(code)
+		}
+	},
+}
+
+// waitAndReport waits with the timeout, poll-interval and stderr-lines flags of pi, returning the exit code
+func waitAndReport(client *predixinsights.Client, pi pi, instanceID string) int {
+	timeout, err := parseDurationFlag(pi, "timeout")
+	if err != nil {
+		fmt.Println("invalid timeout err=" + err.Error())
+		return exitError
+	}
+	interval, err := parseDurationFlag(pi, "poll-interval")
+	if err != nil || interval <= 0 {
+		fmt.Println("invalid poll-interval, expected a duration like 10s")
+		return exitError
+	}
+	instance, err := waitForInstance(client, instanceID, timeout, interval, os.Stdout)
+	if err == errWaitTimeout {
+		fmt.Printf("instance %s still running after %s\n", instanceID, timeout)
+		return exitWaitTimeout
+	}
+	if err != nil {
+		fmt.Println("error waiting for instance err=" + err.Error())
+		return exitError
+	}
+	code := finalStatusCode(instance.Details.FinalApplicationStatus)
+	fmt.Printf("instance %s finished with status %s\n", instanceID, instance.Details.FinalApplicationStatus)
+	if code != 0 {
+		if instance.Details.Diagnostics != "" {
+			fmt.Println(instance.Details.Diagnostics)
+		}
+		if n := pi.V.GetInt("stderr-lines"); n > 0 {
+			if err := printDriverStderr(client, instanceID, n, os.Stdout); err != nil {
+				fmt.Println("error getting driver stderr err=" + err.Error())
+			}
+		}
+	}
+	return code
+}
+
+var errWaitTimeout = errors.New("timeout")
+
+// waitForInstance polls until the YARN application reached a final state, a zero timeout waits forever
+func waitForInstance(client *predixinsights.Client, instanceID string, timeout, interval time.Duration, w io.Writer) (predixinsights.InstanceResponse, error) {
+	start := time.Now()
+	state, progress, failures := "", -1.0, 0
+	for {
+		instance, err := client.GetInstance(instanceID)
+		if err != nil {
+			failures++
+			if failures >= maxPollErrors {
+				return instance, err
+			}
+			fmt.Fprintln(os.Stderr, "warning: polling instance failed err="+err.Error())
+		} else {
+			failures = 0
+			d := instance.Details
+			if d.YarnApplicationState != state || d.Progress != progress {
+				fmt.Fprintf(w, "%s %-10s %3.0f%%\n", time.Now().Format("15:04:05"), d.YarnApplicationState, d.Progress*100)
+				state, progress = d.YarnApplicationState, d.Progress
+			}
+			if finalState(d.YarnApplicationState) {
+				return instance, nil
+			}
+		}
+		if timeout > 0 && time.Since(start)+interval > timeout {
+			return instance, errWaitTimeout
+		}
+		time.Sleep(interval)
+	}
+}
+
+func finalState(state string) bool {
+	return state == "FINISHED" || state == "FAILED" || state == "KILLED"
+}
+
+func finalStatusCode(status string) int {
+	switch status {
+	case "SUCCEEDED":
+		return 0
+	case "KILLED":
+		return exitInstanceKilled
+	}
+	return exitInstanceFailed
+}
+
+// printDriverStderr prints the last n lines of the stderr of the first container, which runs the driver
+func printDriverStderr(client *predixinsights.Client, instanceID string, n int, w io.Writer) error {
+	containers, err := client.GetAllInstanceContainers(instanceID)
+	if err != nil {
+		return err
+	}
+	if len(containers) == 0 {
+		return fmt.Errorf("instance %s has no containers", instanceID)
+	}
+	sort.Slice(containers, func(i, j int) bool { return containers[i].ContainerID < containers[j].ContainerID })
+	logs, err := client.GetInstanceContainerLogs(instanceID, containers[0].ContainerID, predixinsights.ContainerLogSink(0))
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimRight(logs, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	fmt.Fprintf(w, "--- last %d line(s) of driver stderr (container %s) ---\n", len(lines), containers[0].ContainerID)
+	fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return nil
+}
+
+func parseDurationFlag(pi pi, name string) (time.Duration, error) {
+	s := pi.V.GetString(name)
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
@@ -247,8 +247,16 @@ _pi_admin_health-check()
     flags_completion=()
 
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -269,8 +277,16 @@ _pi_admin_version()
     flags_completion=()
 
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -293,8 +309,210 @@ _pi_admin()
     flags_completion=()
 
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_apply()
+{
+    last_command="pi_apply"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--file=")
+    two_word_flags+=("-f")
+    flags+=("--plan")
+    flags+=("--prune")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_backup_create()
+{
+    last_command="pi_backup_create"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--artifactDir=")
+    flags+=("--file=")
+    two_word_flags+=("-f")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_backup_restore()
+{
+    last_command="pi_backup_restore"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--file=")
+    two_word_flags+=("-f")
+    flags+=("--idMap=")
+    flags+=("--plan")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_backup()
+{
+    last_command="pi_backup"
+    commands=()
+    commands+=("create")
+    commands+=("restore")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_cache_clear()
+{
+    last_command="pi_cache_clear"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_cache()
+{
+    last_command="pi_cache"
+    commands=()
+    commands+=("clear")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -321,8 +539,16 @@ _pi_configure()
     flags+=("--TenantID=")
     flags+=("--Token=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -350,8 +576,16 @@ _pi_dag_create()
     flags+=("--dagTemplate=")
     flags+=("--dagVersion=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -375,8 +609,16 @@ _pi_dag_delete()
     flags+=("--force")
     flags+=("-f")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -398,8 +640,16 @@ _pi_dag_deploy()
 
     flags+=("--dagName=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -421,8 +671,16 @@ _pi_dag_list()
 
     flags+=("--dagName=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -445,8 +703,16 @@ _pi_dag_list-run()
     flags+=("--dagName=")
     flags+=("--dagRunID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -469,8 +735,16 @@ _pi_dag_list-task()
     flags+=("--dagName=")
     flags+=("--dagTaskID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -492,8 +766,16 @@ _pi_dag_status()
 
     flags+=("--dagName=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -517,8 +799,16 @@ _pi_dag_task-run-info()
     flags+=("--dagRunID=")
     flags+=("--dagTaskID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -546,8 +836,16 @@ _pi_dag_update()
     flags+=("--dagTemplate=")
     flags+=("--dagVersion=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -577,8 +875,16 @@ _pi_dag()
     flags_completion=()
 
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -602,8 +908,16 @@ _pi_dependency_create()
     flags+=("--dependencyFileName=")
     flags+=("--dependencyType=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -627,8 +941,16 @@ _pi_dependency_delete()
     flags+=("--force")
     flags+=("-f")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -650,8 +972,16 @@ _pi_dependency_deploy()
 
     flags+=("--dependencyID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -673,8 +1003,16 @@ _pi_dependency_list()
 
     flags+=("--dependencyID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -696,8 +1034,16 @@ _pi_dependency_undeploy()
 
     flags+=("--dependencyID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -723,8 +1069,16 @@ _pi_dependency()
     flags_completion=()
 
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -733,9 +1087,9 @@ _pi_dependency()
     noun_aliases=()
 }
 
-_pi_flow_add-config-file()
+_pi_export()
 {
-    last_command="pi_flow_add-config-file"
+    last_command="pi_export"
     commands=()
 
     flags=()
@@ -744,11 +1098,21 @@ _pi_flow_add-config-file()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--configFileDetails=")
-    flags+=("--flowID=")
+    flags+=("--dir=")
+    flags+=("--id=")
+    flags+=("--name=")
+    flags+=("--tag=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -757,9 +1121,9 @@ _pi_flow_add-config-file()
     noun_aliases=()
 }
 
-_pi_flow_create()
+_pi_flow_add-config-file()
 {
-    last_command="pi_flow_create"
+    last_command="pi_flow_add-config-file"
     commands=()
 
     flags=()
@@ -768,11 +1132,23 @@ _pi_flow_create()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--flowName=")
-    flags+=("--flowTemplateID=")
+    flags+=("--configFileDetails=")
+    flags+=("--flowID=")
+    flags+=("--render")
+    flags+=("--secrets=")
+    flags+=("--templateFilePath=")
+    flags+=("--vars=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -781,9 +1157,9 @@ _pi_flow_create()
     noun_aliases=()
 }
 
-_pi_flow_create-direct()
+_pi_flow_clone()
 {
-    last_command="pi_flow_create-direct"
+    last_command="pi_flow_clone"
     commands=()
 
     flags=()
@@ -792,15 +1168,21 @@ _pi_flow_create-direct()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--desc=")
-    flags+=("--flowFileName=")
     flags+=("--flowFilePath=")
-    flags+=("--flowName=")
-    flags+=("--flowType=")
-    flags+=("--flowVersion=")
+    flags+=("--flowID=")
+    flags+=("--flowTemplateID=")
+    flags+=("--name=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -809,9 +1191,9 @@ _pi_flow_create-direct()
     noun_aliases=()
 }
 
-_pi_flow_create-flow-template()
+_pi_flow_config_diff()
 {
-    last_command="pi_flow_create-flow-template"
+    last_command="pi_flow_config_diff"
     commands=()
 
     flags=()
@@ -820,10 +1202,21 @@ _pi_flow_create-flow-template()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--file=")
     flags+=("--flowID=")
+    flags+=("--format=")
+    flags+=("--local=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -832,9 +1225,9 @@ _pi_flow_create-flow-template()
     noun_aliases=()
 }
 
-_pi_flow_delete()
+_pi_flow_config_get()
 {
-    last_command="pi_flow_delete"
+    last_command="pi_flow_config_get"
     commands=()
 
     flags=()
@@ -843,12 +1236,21 @@ _pi_flow_delete()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--file=")
     flags+=("--flowID=")
-    flags+=("--force")
-    flags+=("-f")
+    flags+=("--output=")
+    two_word_flags+=("-o")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -857,9 +1259,9 @@ _pi_flow_delete()
     noun_aliases=()
 }
 
-_pi_flow_delete-config-file()
+_pi_flow_config_pull()
 {
-    last_command="pi_flow_delete-config-file"
+    last_command="pi_flow_config_pull"
     commands=()
 
     flags=()
@@ -868,11 +1270,21 @@ _pi_flow_delete-config-file()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--configFileName=")
+    flags+=("--dir=")
+    flags+=("--exclude=")
     flags+=("--flowID=")
+    flags+=("--include=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -881,9 +1293,9 @@ _pi_flow_delete-config-file()
     noun_aliases=()
 }
 
-_pi_flow_launch()
+_pi_flow_config_render()
 {
-    last_command="pi_flow_launch"
+    last_command="pi_flow_config_render"
     commands=()
 
     flags=()
@@ -892,11 +1304,20 @@ _pi_flow_launch()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--flowID=")
-    flags+=("--flowTemplateID=")
+    flags+=("--file=")
+    flags+=("--secrets=")
+    flags+=("--vars=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -905,9 +1326,9 @@ _pi_flow_launch()
     noun_aliases=()
 }
 
-_pi_flow_list()
+_pi_flow_config_sync()
 {
-    last_command="pi_flow_list"
+    last_command="pi_flow_config_sync"
     commands=()
 
     flags=()
@@ -916,12 +1337,27 @@ _pi_flow_list()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--dir=")
+    flags+=("--exclude=")
     flags+=("--flowID=")
-    flags+=("--flowName=")
-    flags+=("--flowTemplateID=")
+    flags+=("--include=")
+    flags+=("--plan")
+    flags+=("--prune")
+    flags+=("--render")
+    flags+=("--secrets=")
+    flags+=("--templateFilePath=")
+    flags+=("--vars=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -930,10 +1366,15 @@ _pi_flow_list()
     noun_aliases=()
 }
 
-_pi_flow_list-config-files()
+_pi_flow_config()
 {
-    last_command="pi_flow_list-config-files"
+    last_command="pi_flow_config"
     commands=()
+    commands+=("diff")
+    commands+=("get")
+    commands+=("pull")
+    commands+=("render")
+    commands+=("sync")
 
     flags=()
     two_word_flags=()
@@ -941,10 +1382,17 @@ _pi_flow_list-config-files()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--flowID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -953,9 +1401,9 @@ _pi_flow_list-config-files()
     noun_aliases=()
 }
 
-_pi_flow_list-tags()
+_pi_flow_create()
 {
-    last_command="pi_flow_list-tags"
+    last_command="pi_flow_create"
     commands=()
 
     flags=()
@@ -964,11 +1412,22 @@ _pi_flow_list-tags()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--flowID=")
+    flags+=("--flowName=")
     flags+=("--flowTemplateID=")
+    flags+=("--preset=")
+    flags+=("--tags=")
+    flags+=("--ttl=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -977,9 +1436,9 @@ _pi_flow_list-tags()
     noun_aliases=()
 }
 
-_pi_flow_save-tags()
+_pi_flow_create-direct()
 {
-    last_command="pi_flow_save-tags"
+    last_command="pi_flow_create-direct"
     commands=()
 
     flags=()
@@ -988,12 +1447,25 @@ _pi_flow_save-tags()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--flowID=")
-    flags+=("--flowTemplateID=")
+    flags+=("--desc=")
+    flags+=("--flowFileName=")
+    flags+=("--flowFilePath=")
+    flags+=("--flowName=")
+    flags+=("--flowType=")
+    flags+=("--flowVersion=")
     flags+=("--tags=")
+    flags+=("--ttl=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1002,9 +1474,9 @@ _pi_flow_save-tags()
     noun_aliases=()
 }
 
-_pi_flow_stop()
+_pi_flow_create-flow-template()
 {
-    last_command="pi_flow_stop"
+    last_command="pi_flow_create-flow-template"
     commands=()
 
     flags=()
@@ -1013,10 +1485,18 @@ _pi_flow_stop()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--flowName=")
+    flags+=("--flowID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1025,9 +1505,9 @@ _pi_flow_stop()
     noun_aliases=()
 }
 
-_pi_flow_update-direct()
+_pi_flow_delete()
 {
-    last_command="pi_flow_update-direct"
+    last_command="pi_flow_delete"
     commands=()
 
     flags=()
@@ -1036,13 +1516,20 @@ _pi_flow_update-direct()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--desc=")
-    flags+=("--flowFileName=")
-    flags+=("--flowFilePath=")
     flags+=("--flowID=")
+    flags+=("--force")
+    flags+=("-f")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1051,9 +1538,9 @@ _pi_flow_update-direct()
     noun_aliases=()
 }
 
-_pi_flow_update-spark-args()
+_pi_flow_delete-config-file()
 {
-    last_command="pi_flow_update-spark-args"
+    last_command="pi_flow_delete-config-file"
     commands=()
 
     flags=()
@@ -1062,12 +1549,19 @@ _pi_flow_update-spark-args()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--configFileName=")
     flags+=("--flowID=")
-    flags+=("--flowTemplateID=")
-    flags+=("--sparkArgs=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1076,24 +1570,10 @@ _pi_flow_update-spark-args()
     noun_aliases=()
 }
 
-_pi_flow()
+_pi_flow_diff()
 {
-    last_command="pi_flow"
+    last_command="pi_flow_diff"
     commands=()
-    commands+=("add-config-file")
-    commands+=("create")
-    commands+=("create-direct")
-    commands+=("create-flow-template")
-    commands+=("delete")
-    commands+=("delete-config-file")
-    commands+=("launch")
-    commands+=("list")
-    commands+=("list-config-files")
-    commands+=("list-tags")
-    commands+=("save-tags")
-    commands+=("stop")
-    commands+=("update-direct")
-    commands+=("update-spark-args")
 
     flags=()
     two_word_flags=()
@@ -1101,9 +1581,19 @@ _pi_flow()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--against-template")
+    flags+=("--format=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1112,9 +1602,9 @@ _pi_flow()
     noun_aliases=()
 }
 
-_pi_flow-template_create()
+_pi_flow_edit()
 {
-    last_command="pi_flow-template_create"
+    last_command="pi_flow_edit"
     commands=()
 
     flags=()
@@ -1123,15 +1613,18 @@ _pi_flow-template_create()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--desc=")
-    flags+=("--flowTemplateName=")
-    flags+=("--flowTemplateVersion=")
-    flags+=("--flowType=")
-    flags+=("--templateFileName=")
-    flags+=("--templateFilePath=")
+    flags+=("--flowID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1140,9 +1633,9 @@ _pi_flow-template_create()
     noun_aliases=()
 }
 
-_pi_flow-template_delete()
+_pi_flow_launch()
 {
-    last_command="pi_flow-template_delete"
+    last_command="pi_flow_launch"
     commands=()
 
     flags=()
@@ -1151,12 +1644,25 @@ _pi_flow-template_delete()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--flowID=")
     flags+=("--flowTemplateID=")
-    flags+=("--force")
-    flags+=("-f")
+    flags+=("--poll-interval=")
+    flags+=("--set=")
+    flags+=("--stderr-lines=")
+    flags+=("--templateFilePath=")
+    flags+=("--timeout=")
+    flags+=("--wait")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1165,9 +1671,9 @@ _pi_flow-template_delete()
     noun_aliases=()
 }
 
-_pi_flow-template_list()
+_pi_flow_list()
 {
-    last_command="pi_flow-template_list"
+    last_command="pi_flow_list"
     commands=()
 
     flags=()
@@ -1176,11 +1682,20 @@ _pi_flow-template_list()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--flowID=")
+    flags+=("--flowName=")
     flags+=("--flowTemplateID=")
-    flags+=("--flowTemplateName=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1189,9 +1704,9 @@ _pi_flow-template_list()
     noun_aliases=()
 }
 
-_pi_flow-template_list-tags()
+_pi_flow_list-config-files()
 {
-    last_command="pi_flow-template_list-tags"
+    last_command="pi_flow_list-config-files"
     commands=()
 
     flags=()
@@ -1200,10 +1715,18 @@ _pi_flow-template_list-tags()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--flowTemplateID=")
+    flags+=("--flowID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1212,9 +1735,402 @@ _pi_flow-template_list-tags()
     noun_aliases=()
 }
 
-_pi_flow-template_save-tags()
+_pi_flow_list-tags()
 {
-    last_command="pi_flow-template_save-tags"
+    last_command="pi_flow_list-tags"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--flowID=")
+    flags+=("--flowTemplateID=")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_flow_save-tags()
+{
+    last_command="pi_flow_save-tags"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--flowID=")
+    flags+=("--flowTemplateID=")
+    flags+=("--tags=")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_flow_stop()
+{
+    last_command="pi_flow_stop"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--flowName=")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_flow_update-direct()
+{
+    last_command="pi_flow_update-direct"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--desc=")
+    flags+=("--flowFileName=")
+    flags+=("--flowFilePath=")
+    flags+=("--flowID=")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_flow_update-spark-args()
+{
+    last_command="pi_flow_update-spark-args"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--app-arg=")
+    flags+=("--className=")
+    flags+=("--conf=")
+    flags+=("--driverCores=")
+    flags+=("--driverJavaOptions=")
+    flags+=("--driverMemory=")
+    flags+=("--executor-env=")
+    flags+=("--executorJavaOptions=")
+    flags+=("--executorMemory=")
+    flags+=("--fileName=")
+    flags+=("--flowID=")
+    flags+=("--flowTemplateID=")
+    flags+=("--frameworkName=")
+    flags+=("--listener=")
+    flags+=("--logConf=")
+    flags+=("--merge")
+    flags+=("--numExecutors=")
+    flags+=("--preset=")
+    flags+=("--replace")
+    flags+=("--sparkArgs=")
+    flags+=("--system-prop=")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_flow()
+{
+    last_command="pi_flow"
+    commands=()
+    commands+=("add-config-file")
+    commands+=("clone")
+    commands+=("config")
+    commands+=("create")
+    commands+=("create-direct")
+    commands+=("create-flow-template")
+    commands+=("delete")
+    commands+=("delete-config-file")
+    commands+=("diff")
+    commands+=("edit")
+    commands+=("launch")
+    commands+=("list")
+    commands+=("list-config-files")
+    commands+=("list-tags")
+    commands+=("save-tags")
+    commands+=("stop")
+    commands+=("update-direct")
+    commands+=("update-spark-args")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_flow-template_create()
+{
+    last_command="pi_flow-template_create"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--desc=")
+    flags+=("--flowTemplateName=")
+    flags+=("--flowTemplateVersion=")
+    flags+=("--flowType=")
+    flags+=("--tags=")
+    flags+=("--templateFileName=")
+    flags+=("--templateFilePath=")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_flow-template_delete()
+{
+    last_command="pi_flow-template_delete"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--flowTemplateID=")
+    flags+=("--force")
+    flags+=("-f")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_flow-template_edit()
+{
+    last_command="pi_flow-template_edit"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--flowTemplateID=")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_flow-template_list()
+{
+    last_command="pi_flow-template_list"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--flowTemplateID=")
+    flags+=("--flowTemplateName=")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_flow-template_list-tags()
+{
+    last_command="pi_flow-template_list-tags"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--flowTemplateID=")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_flow-template_save-tags()
+{
+    last_command="pi_flow-template_save-tags"
     commands=()
 
     flags=()
@@ -1226,8 +2142,16 @@ _pi_flow-template_save-tags()
     flags+=("--flowTemplateID=")
     flags+=("--tags=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1255,8 +2179,16 @@ _pi_flow-template_update()
     flags+=("--templateFileName=")
     flags+=("--templateFilePath=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1276,11 +2208,37 @@ _pi_flow-template_update-spark-args()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--app-arg=")
+    flags+=("--className=")
+    flags+=("--conf=")
+    flags+=("--driverCores=")
+    flags+=("--driverJavaOptions=")
+    flags+=("--driverMemory=")
+    flags+=("--executor-env=")
+    flags+=("--executorJavaOptions=")
+    flags+=("--executorMemory=")
+    flags+=("--fileName=")
     flags+=("--flowTemplateID=")
+    flags+=("--frameworkName=")
+    flags+=("--listener=")
+    flags+=("--logConf=")
+    flags+=("--merge")
+    flags+=("--numExecutors=")
+    flags+=("--preset=")
+    flags+=("--replace")
     flags+=("--sparkArgs=")
+    flags+=("--system-prop=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1295,6 +2253,7 @@ _pi_flow-template()
     commands=()
     commands+=("create")
     commands+=("delete")
+    commands+=("edit")
     commands+=("list")
     commands+=("list-tags")
     commands+=("save-tags")
@@ -1308,8 +2267,49 @@ _pi_flow-template()
     flags_completion=()
 
     flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_gc()
+{
+    last_command="pi_gc"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--min-age=")
+    flags+=("--yes")
+    flags+=("-y")
+    flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1332,8 +2332,16 @@ _pi_instance_list-app-stages()
     flags+=("--attemptID=")
     flags+=("--instanceID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1358,8 +2366,16 @@ _pi_instance_list-attempt-details()
     flags+=("--stageAttemptID=")
     flags+=("--stageID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1383,8 +2399,16 @@ _pi_instance_list-attempts()
     flags+=("--instanceID=")
     flags+=("--stageID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1410,8 +2434,16 @@ _pi_instance_list-container-logs()
     flags+=("--tail")
     flags+=("-t")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1434,8 +2466,16 @@ _pi_instance_list-container-response()
     flags+=("--containerID=")
     flags+=("--instanceID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1457,8 +2497,16 @@ _pi_instance_list-containers()
 
     flags+=("--instanceID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1480,8 +2528,16 @@ _pi_instance_list-instance()
 
     flags+=("--instanceID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1503,8 +2559,16 @@ _pi_instance_list-spark-app-details()
 
     flags+=("--instanceID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1527,8 +2591,16 @@ _pi_instance_list-spark-executor-details()
     flags+=("--attemptID=")
     flags+=("--instanceID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1550,8 +2622,16 @@ _pi_instance_list-submit-logs()
 
     flags+=("--instanceID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1576,8 +2656,16 @@ _pi_instance_list-tasks()
     flags+=("--stageAttemptID=")
     flags+=("--stageID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1599,8 +2687,50 @@ _pi_instance_stop()
 
     flags+=("--instanceID=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_instance_wait()
+{
+    last_command="pi_instance_wait"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--instanceID=")
+    flags+=("--poll-interval=")
+    flags+=("--stderr-lines=")
+    flags+=("--timeout=")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1625,6 +2755,301 @@ _pi_instance()
     commands+=("list-submit-logs")
     commands+=("list-tasks")
     commands+=("stop")
+    commands+=("wait")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_preset_diff()
+{
+    last_command="pi_preset_diff"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--against=")
+    flags+=("--flowID=")
+    flags+=("--flowTemplateID=")
+    flags+=("--preset=")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_preset_list()
+{
+    last_command="pi_preset_list"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_preset_show()
+{
+    last_command="pi_preset_show"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--preset=")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_preset()
+{
+    last_command="pi_preset"
+    commands=()
+    commands+=("diff")
+    commands+=("list")
+    commands+=("show")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_promote()
+{
+    last_command="pi_promote"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--artifactDir=")
+    flags+=("--force")
+    flags+=("-f")
+    flags+=("--from=")
+    flags+=("--id=")
+    flags+=("--name=")
+    flags+=("--overlay=")
+    flags+=("--plan")
+    flags+=("--tag=")
+    flags+=("--to=")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_spark-args_from-submit()
+{
+    last_command="pi_spark-args_from-submit"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--from-properties=")
+    flags+=("--instanceID=")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_spark-args_to-submit()
+{
+    last_command="pi_spark-args_to-submit"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--flowID=")
+    flags+=("--master=")
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_spark-args()
+{
+    last_command="pi_spark-args"
+    commands=()
+    commands+=("from-submit")
+    commands+=("to-submit")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--config=")
+    flags+=("--dry-run")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_pi_sweep()
+{
+    last_command="pi_sweep"
+    commands=()
 
     flags=()
     two_word_flags=()
@@ -1632,9 +3057,22 @@ _pi_instance()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--concurrency=")
+    flags+=("--file=")
+    two_word_flags+=("-f")
+    flags+=("--keep")
+    flags+=("--results=")
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1648,12 +3086,21 @@ _pi_root_command()
     last_command="pi"
     commands=()
     commands+=("admin")
+    commands+=("apply")
+    commands+=("backup")
+    commands+=("cache")
     commands+=("configure")
     commands+=("dag")
     commands+=("dependency")
+    commands+=("export")
     commands+=("flow")
     commands+=("flow-template")
+    commands+=("gc")
     commands+=("instance")
+    commands+=("preset")
+    commands+=("promote")
+    commands+=("spark-args")
+    commands+=("sweep")
 
     flags=()
     two_word_flags=()
@@ -1662,8 +3109,16 @@ _pi_root_command()
     flags_completion=()
 
     flags+=("--config=")
+    flags+=("--dry-run")
     flags+=("--interactive")
     flags+=("-i")
+    flags+=("--max-in-flight=")
+    flags+=("--max-rps=")
+    flags+=("--no-cache")
+    flags+=("--override-policy")
+    flags+=("--policy=")
+    flags+=("--record=")
+    flags+=("--replay=")
     flags+=("--verbose")
     flags+=("-v")
 